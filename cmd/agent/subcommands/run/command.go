@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package run implements 'agent run'.
+package run
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	pidfilePath string
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+	bundleParams := command.GetDefaultCoreBundleParams(cliParams.GlobalParams)
+	bundleParams.ConfigLoadSecrets = true
+
+	runCmd := &cobra.Command{
+		Use:     "run",
+		Aliases: []string{"start"},
+		Short:   "Run the Agent",
+		Long:    `Runs the agent in the foreground until it's sent a termination signal.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(run,
+				fx.Supply(cliParams),
+				fx.Supply(bundleParams),
+				core.Bundle(),
+			)
+		},
+	}
+	runCmd.Flags().StringVarP(&cliParams.pidfilePath, "pidfile", "p", "", "path to the pidfile")
+
+	return []*cobra.Command{runCmd}
+}
+
+func run(config config.Component, params *cliParams, _ log.Component) error {
+	if params.pidfilePath != "" {
+		if err := writePIDFile(params.pidfilePath); err != nil {
+			return err
+		}
+		defer os.Remove(params.pidfilePath)
+	}
+
+	runPath := config.GetString("run_path")
+	ipcAddress, err := pkgconfigsetup.GetIPCAddress(pkgconfigsetup.Datadog())
+	if err != nil {
+		return err
+	}
+
+	// Publish the descriptor other CLI commands (stop, status, flare, ...)
+	// auto-discover via util.DiscoverRunningAgent, so they can find this
+	// agent's actual IPC endpoint even if it was started with config
+	// overrides that differ from whatever config file they'd otherwise read.
+	desc := util.RunningAgentDescriptor{
+		IPCAddress:    ipcAddress,
+		CmdPort:       config.GetInt("cmd_port"),
+		AuthTokenPath: config.GetString("auth_token_file_path"),
+		PID:           os.Getpid(),
+		Version:       version.AgentVersion,
+	}
+	if err := util.WriteRunningAgentDescriptor(runPath, desc); err != nil {
+		return fmt.Errorf("failed to write agent runtime descriptor: %w", err)
+	}
+	defer util.RemoveRunningAgentDescriptor(runPath)
+
+	stopCh := make(chan os.Signal, 1)
+	signal.Notify(stopCh, os.Interrupt, syscall.SIGTERM)
+	<-stopCh
+
+	return nil
+}
+
+// writePIDFile writes the current process's PID to path, as requested by
+// --pidfile, for external supervisors that track the agent by PID file
+// rather than by service manager.
+func writePIDFile(path string) error {
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644)
+}
@@ -11,6 +11,11 @@ package stop
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -24,9 +29,18 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
 
+const (
+	defaultStopTimeout = 30 * time.Second
+	pollInterval       = 200 * time.Millisecond
+)
+
 // cliParams are the command-line arguments for this subcommand
 type cliParams struct {
 	*command.GlobalParams
+
+	timeout time.Duration
+	force   bool
+	wait    bool
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -46,11 +60,14 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 			)
 		},
 	}
+	stopCmd.Flags().DurationVar(&cliParams.timeout, "timeout", defaultStopTimeout, "how long to wait for the agent to exit before giving up (or force-killing, with --force)")
+	stopCmd.Flags().BoolVar(&cliParams.force, "force", false, "send SIGTERM then SIGKILL to the agent process if it hasn't exited by --timeout")
+	stopCmd.Flags().BoolVar(&cliParams.wait, "wait", false, "poll the agent's PID until the process has actually exited before returning")
 
 	return []*cobra.Command{stopCmd}
 }
 
-func stop(config config.Component, _ *cliParams, _ log.Component) error {
+func stop(config config.Component, params *cliParams, _ log.Component) error {
 	// Global Agent configuration
 	c := util.GetClient()
 
@@ -59,11 +76,21 @@ func stop(config config.Component, _ *cliParams, _ log.Component) error {
 	if e != nil {
 		return e
 	}
-	ipcAddress, err := pkgconfigsetup.GetIPCAddress(pkgconfigsetup.Datadog())
-	if err != nil {
-		return err
+
+	ipcAddress, cmdPort := config.GetString("cmd_host"), config.GetInt("cmd_port")
+	if desc, err := util.DiscoverRunningAgent(config.GetString("run_path")); err == nil {
+		// Prefer the running agent's own descriptor over the local config:
+		// they can disagree if the agent was started with a different
+		// config, or the config drifted after start.
+		ipcAddress, cmdPort = desc.IPCAddress, desc.CmdPort
+	} else {
+		var ipcErr error
+		ipcAddress, ipcErr = pkgconfigsetup.GetIPCAddress(pkgconfigsetup.Datadog())
+		if ipcErr != nil {
+			return ipcErr
+		}
 	}
-	urlstr := fmt.Sprintf("https://%v:%v/agent/stop", ipcAddress, config.GetInt("cmd_port"))
+	urlstr := fmt.Sprintf("https://%v:%v/agent/stop", ipcAddress, cmdPort)
 
 	_, e = util.DoPost(c, urlstr, "application/json", bytes.NewBuffer([]byte{}))
 	if e != nil {
@@ -71,5 +98,178 @@ func stop(config config.Component, _ *cliParams, _ log.Component) error {
 	}
 
 	fmt.Println("Agent successfully stopped")
+
+	if !params.wait && !params.force {
+		return nil
+	}
+
+	pid, err := readPIDFile(config.GetString("pidfile"))
+	if err != nil {
+		// The IPC call succeeded, but we can't verify the process actually
+		// exited without a PID: report the IPC success and move on rather
+		// than failing a stop that may well have worked.
+		fmt.Printf("Warning: could not determine agent PID to verify it exited: %v\n", err)
+		return nil
+	}
+
+	// Capture the process's identity (PID + start time) before we start
+	// polling/signaling, so that if the agent exits and the kernel recycles
+	// its PID to an unrelated process while we're still looking, we notice
+	// instead of signaling a stranger.
+	identity, err := readProcessIdentity(pid)
+	if err != nil {
+		fmt.Printf("Warning: could not read identity of agent process (pid %d): %v\n", pid, err)
+		return nil
+	}
+
+	exited, err := waitForProcessExit(identity, params.timeout)
+	if err != nil {
+		return fmt.Errorf("IPC succeeded but process still alive: %v", err)
+	}
+	if exited {
+		return nil
+	}
+
+	if !params.force {
+		return fmt.Errorf("IPC succeeded but process still alive: agent (pid %d) did not exit within %s", pid, params.timeout)
+	}
+
+	fmt.Printf("Agent (pid %d) did not exit within %s, escalating\n", pid, params.timeout)
+	if err := escalateSignals(identity); err != nil {
+		return fmt.Errorf("IPC succeeded but process still alive: failed to force-kill agent (pid %d): %v", pid, err)
+	}
+
+	fmt.Println("Agent process terminated")
+	return nil
+}
+
+// readPIDFile reads and parses the PID written by the running agent at path.
+func readPIDFile(path string) (int, error) {
+	if path == "" {
+		return 0, fmt.Errorf("no pidfile configured")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pidfile contents: %v", err)
+	}
+	return pid, nil
+}
+
+// processIdentity pins a PID to the process that held it when we last
+// looked, so a later check can tell whether the kernel has since recycled
+// that PID to an unrelated process.
+type processIdentity struct {
+	pid       int
+	startTime string
+}
+
+// readProcessIdentity captures pid's current identity via its /proc start
+// time. On platforms without /proc (e.g. macOS), startTime is left empty
+// and identity checks degrade to a plain liveness check.
+func readProcessIdentity(pid int) (processIdentity, error) {
+	if !processAlive(pid) {
+		return processIdentity{}, fmt.Errorf("process (pid %d) is not running", pid)
+	}
+	startTime, err := readProcStartTime(pid)
+	if err != nil && !os.IsNotExist(err) {
+		return processIdentity{}, err
+	}
+	return processIdentity{pid: pid, startTime: startTime}, nil
+}
+
+// readProcStartTime reads field 22 (starttime) of /proc/<pid>/stat. The
+// comm field (2) is parenthesized and may itself contain spaces or
+// parens, so we locate it by its closing ')' rather than splitting the
+// whole line on whitespace.
+func readProcStartTime(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "", err
+	}
+	end := strings.LastIndex(string(data), ")")
+	if end < 0 {
+		return "", fmt.Errorf("malformed /proc/%d/stat: no comm field", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	const startTimeField = 22 - 3 // fields[0] here is stat field 3 (state)
+	if len(fields) <= startTimeField {
+		return "", fmt.Errorf("malformed /proc/%d/stat: too few fields", pid)
+	}
+	return fields[startTimeField], nil
+}
+
+// sameProcess reports whether identity still refers to a live process with
+// the same start time, i.e. the PID hasn't been recycled since we read it.
+func sameProcess(identity processIdentity) bool {
+	if !processAlive(identity.pid) {
+		return false
+	}
+	if identity.startTime == "" {
+		return true
+	}
+	startTime, err := readProcStartTime(identity.pid)
+	if err != nil {
+		return true
+	}
+	return startTime == identity.startTime
+}
+
+// processAlive reports whether pid is still running, using signal 0 which
+// performs the kernel's existence/permission checks without actually
+// signaling the process.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// waitForProcessExit polls identity until its process is gone (or no
+// longer the same process) or timeout elapses.
+func waitForProcessExit(identity processIdentity, timeout time.Duration) (exited bool, err error) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if !sameProcess(identity) {
+			return true, nil
+		}
+		time.Sleep(pollInterval)
+	}
+	return !sameProcess(identity), nil
+}
+
+// escalateSignals sends SIGTERM, waits briefly for a graceful exit, then
+// SIGKILL if the process is still alive. It re-checks identity before each
+// signal so a PID recycled mid-escalation is never signaled.
+func escalateSignals(identity processIdentity) error {
+	if !sameProcess(identity) {
+		return nil
+	}
+	process, err := os.FindProcess(identity.pid)
+	if err != nil {
+		return err
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err == nil {
+		exited, _ := waitForProcessExit(identity, 5*time.Second)
+		if exited {
+			return nil
+		}
+	}
+
+	if !sameProcess(identity) {
+		return nil
+	}
+	if err := process.Signal(syscall.SIGKILL); err != nil {
+		return err
+	}
+	exited, _ := waitForProcessExit(identity, 5*time.Second)
+	if !exited {
+		return fmt.Errorf("agent (pid %d) still alive after SIGKILL", identity.pid)
+	}
 	return nil
 }
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrethelper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// auditWriter is where structured audit entries are written. It defaults to
+// stderr so audit entries don't interleave with the JSON response written to
+// stdout, and is swapped out by tests. configureAuditLog appends a file to
+// it when --audit-log is set.
+var auditWriter io.Writer = os.Stderr
+
+// auditEntry is a single structured audit record describing one secret fetch
+// attempt. Entries are written as newline-delimited JSON so they can be
+// shipped to a log pipeline without further parsing. The secret's own id is
+// never logged in the clear (a compliance requirement for this audit
+// trail); IDHash lets the same secret's attempts be correlated across
+// entries without exposing what the id actually is.
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	IDHash     string    `json:"id_hash"`
+	Provider   string    `json:"provider"`
+	CacheHit   bool      `json:"cache_hit"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS float64   `json:"duration_ms,omitempty"`
+	PID        int       `json:"pid"`
+	UID        int       `json:"uid"`
+}
+
+// hashSecretID digests id with sha256 so auditEntry.IDHash can correlate
+// repeated attempts at the same secret without the audit log ever holding
+// the id itself.
+func hashSecretID(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// logAudit writes a single structured audit entry to w. Marshaling failures
+// are swallowed: a missing audit line must never fail a secret fetch.
+func logAudit(w io.Writer, entry auditEntry) {
+	out, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	out = append(out, '\n')
+	_, _ = w.Write(out)
+}
+
+// configureAuditLog points auditWriter at path in addition to stderr, so
+// audit entries are both visible to an operator watching stderr and
+// durably captured for a compliance pipeline. It returns a closer the
+// caller must close once done logging; a no-op closer is returned when
+// path is empty.
+func configureAuditLog(path string) (io.Closer, error) {
+	if path == "" {
+		return io.NopCloser(nil), nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %q: %w", path, err)
+	}
+
+	auditWriter = io.MultiWriter(os.Stderr, f)
+	return f, nil
+}
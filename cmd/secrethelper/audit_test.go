@@ -0,0 +1,28 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrethelper
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogAudit(t *testing.T) {
+	var buf bytes.Buffer
+	logAudit(&buf, auditEntry{IDHash: hashSecretID("some_id"), Provider: "file", Success: true})
+
+	var got auditEntry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, hashSecretID("some_id"), got.IDHash)
+	assert.NotEqual(t, "some_id", got.IDHash, "the raw secret id must never be logged")
+	assert.Equal(t, "file", got.Provider)
+	assert.True(t, got.Success)
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("\n")))
+}
@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrethelper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// defaultCacheTTL is the --cache-ttl flag's own default, and the flag's value
+// applies to every secret fetched through the v1 payload (which has no
+// notion of cache_ttl_seconds). v2 secrets that leave cache_ttl_seconds
+// unset use defaultCacheTTL itself; --cache-ttl doesn't apply to them.
+const defaultCacheTTL = 30 * time.Second
+
+type cacheEntry struct {
+	val       secrets.SecretVal
+	expiresAt time.Time
+}
+
+// diskCacheEntry is cacheEntry's on-disk encoding: val/expiresAt aren't
+// exported, so they can't be marshaled directly.
+type diskCacheEntry struct {
+	Val       secrets.SecretVal `json:"val"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// secretCache memoizes fetched secrets, keyed by provider+id, first in an
+// in-process map (so a secret referenced more than once in the same
+// request is only fetched once) and, when dir is non-empty, also on disk
+// under dir so the cache survives across separate "secret-helper read"
+// invocations. Large agent deployments call the secret-helper many times
+// per minute, often for the same secrets, so the cross-invocation case is
+// the one --cache-dir exists to address; the in-process map alone only
+// helps within a single invocation.
+type secretCache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	dir     string
+}
+
+// newSecretCache builds a cache that memoizes in-process and, when dir is
+// non-empty, also on disk under dir. dir may be empty, in which case only
+// the in-process, single-invocation memoization applies.
+func newSecretCache(dir string) *secretCache {
+	return &secretCache{entries: make(map[string]cacheEntry), dir: dir}
+}
+
+func (c *secretCache) get(key string) (secrets.SecretVal, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		if time.Now().After(entry.expiresAt) {
+			delete(c.entries, key)
+			return secrets.SecretVal{}, false
+		}
+		return entry.val, true
+	}
+
+	if c.dir == "" {
+		return secrets.SecretVal{}, false
+	}
+
+	entry, ok := c.readDiskEntry(key)
+	if !ok {
+		return secrets.SecretVal{}, false
+	}
+	c.entries[key] = entry
+	return entry.val, true
+}
+
+func (c *secretCache) set(key string, val secrets.SecretVal, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := cacheEntry{val: val, expiresAt: time.Now().Add(ttl)}
+	c.entries[key] = entry
+
+	if c.dir != "" {
+		// A cache write failing (e.g. a read-only --cache-dir) must never
+		// fail the secret fetch it's memoizing; the in-process entry set
+		// above still serves the rest of this invocation either way.
+		_ = c.writeDiskEntry(key, entry)
+	}
+}
+
+// diskPath returns the on-disk path for key, under c.dir. key is hashed
+// rather than used as a filename directly since it's built from a secret
+// id and may contain path separators or other characters unsafe in a file
+// name.
+func (c *secretCache) diskPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *secretCache) readDiskEntry(key string) (cacheEntry, bool) {
+	raw, err := os.ReadFile(c.diskPath(key))
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	var disk diskCacheEntry
+	if err := json.Unmarshal(raw, &disk); err != nil {
+		return cacheEntry{}, false
+	}
+	if time.Now().After(disk.ExpiresAt) {
+		return cacheEntry{}, false
+	}
+
+	return cacheEntry{val: disk.Val, expiresAt: disk.ExpiresAt}, true
+}
+
+// writeDiskEntry persists entry under c.dir, writing to a temp file first
+// and renaming it into place so a concurrent reader never observes a
+// partially written cache file.
+func (c *secretCache) writeDiskEntry(key string, entry cacheEntry) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+
+	out, err := json.Marshal(diskCacheEntry{Val: entry.val, ExpiresAt: entry.expiresAt})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.diskPath(key))
+}
+
+// fetchWithCache resolves a secret through fetch, serving a cached value
+// instead if one is still within its TTL, and recording an audit entry for
+// the attempt either way.
+func fetchWithCache(cache *secretCache, provider string, id string, ttl time.Duration, fetch func() secrets.SecretVal) secrets.SecretVal {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+
+	key := provider + ":" + id
+	start := time.Now()
+
+	if val, ok := cache.get(key); ok {
+		logAudit(auditWriter, auditEntry{
+			Time:     start,
+			IDHash:   hashSecretID(id),
+			Provider: provider,
+			CacheHit: true,
+			Success:  val.ErrorMsg == "",
+			Error:    val.ErrorMsg,
+			PID:      os.Getpid(),
+			UID:      os.Getuid(),
+		})
+		return val
+	}
+
+	val := fetch()
+	cache.set(key, val, ttl)
+	logAudit(auditWriter, auditEntry{
+		Time:       start,
+		IDHash:     hashSecretID(id),
+		Provider:   provider,
+		CacheHit:   false,
+		Success:    val.ErrorMsg == "",
+		Error:      val.ErrorMsg,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		PID:        os.Getpid(),
+		UID:        os.Getuid(),
+	})
+	return val
+}
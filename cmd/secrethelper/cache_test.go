@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrethelper
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+func TestFetchWithCacheDedupesCallsWithinTTL(t *testing.T) {
+	var buf bytes.Buffer
+	old := auditWriter
+	auditWriter = &buf
+	defer func() { auditWriter = old }()
+
+	cache := newSecretCache("")
+	calls := 0
+	fetch := func() secrets.SecretVal {
+		calls++
+		return secrets.SecretVal{Value: "value"}
+	}
+
+	first := fetchWithCache(cache, "file", "some_id", time.Minute, fetch)
+	second := fetchWithCache(cache, "file", "some_id", time.Minute, fetch)
+
+	assert.Equal(t, secrets.SecretVal{Value: "value"}, first)
+	assert.Equal(t, secrets.SecretVal{Value: "value"}, second)
+	assert.Equal(t, 1, calls, "second fetch should have been served from cache")
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("\n")), "both attempts should be audited")
+}
+
+func TestFetchWithCacheExpiresAfterTTL(t *testing.T) {
+	cache := newSecretCache("")
+	calls := 0
+	fetch := func() secrets.SecretVal {
+		calls++
+		return secrets.SecretVal{Value: "value"}
+	}
+
+	fetchWithCache(cache, "file", "some_id", time.Nanosecond, fetch)
+	time.Sleep(time.Millisecond)
+	fetchWithCache(cache, "file", "some_id", time.Nanosecond, fetch)
+
+	assert.Equal(t, 2, calls, "expired entries should be re-fetched")
+}
+
+// TestFetchWithCachePersistsAcrossInstances covers the on-disk cache's
+// actual purpose: a secret fetched by one secretCache instance (standing in
+// for one "secret-helper read" process invocation) should be served from
+// disk by a second, independent instance pointed at the same --cache-dir,
+// without calling fetch again.
+func TestFetchWithCachePersistsAcrossInstances(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	fetch := func() secrets.SecretVal {
+		calls++
+		return secrets.SecretVal{Value: "value"}
+	}
+
+	first := newSecretCache(dir)
+	fetchWithCache(first, "file", "some_id", time.Minute, fetch)
+
+	second := newSecretCache(dir)
+	val := fetchWithCache(second, "file", "some_id", time.Minute, fetch)
+
+	assert.Equal(t, secrets.SecretVal{Value: "value"}, val)
+	assert.Equal(t, 1, calls, "second instance should have found the first instance's entry on disk")
+}
+
+func TestFetchWithCacheDiskEntryExpiresAfterTTL(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	fetch := func() secrets.SecretVal {
+		calls++
+		return secrets.SecretVal{Value: "value"}
+	}
+
+	first := newSecretCache(dir)
+	fetchWithCache(first, "file", "some_id", time.Nanosecond, fetch)
+	time.Sleep(time.Millisecond)
+
+	second := newSecretCache(dir)
+	fetchWithCache(second, "file", "some_id", time.Nanosecond, fetch)
+
+	assert.Equal(t, 2, calls, "expired on-disk entries should be re-fetched, not served stale")
+}
@@ -0,0 +1,226 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// ExecProviderPrefix is the URI scheme used in the v2.0 "providers" section
+// to designate an external executable speaking the plugin protocol, e.g.
+// "exec:///path/to/binary".
+const ExecProviderPrefix = "exec://"
+
+// execRequest is a single request sent to an external secret provider, one
+// per line, on its stdin.
+type execRequest struct {
+	ID     string            `json:"id"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// execResponse is a single response read back from an external secret
+// provider, one per line, on its stdout.
+type execResponse struct {
+	ID    string `json:"id"`
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// execReaderResult is a single decoded line read back from the plugin's
+// stdout, or the error from trying to decode it.
+type execReaderResult struct {
+	resp execResponse
+	err  error
+}
+
+// ExecProvider talks the secrethelper plugin protocol to a single external
+// executable over its stdin/stdout: one JSON request per line in, one JSON
+// response per line out. Requests are serialized: only one is in flight at a
+// time, which keeps response/request matching trivial even if the plugin
+// doesn't preserve ordering across concurrent requests.
+//
+// stdout is read by a single long-lived goroutine (readLoop), not one
+// goroutine per Read call: a request that times out leaves its response
+// (if the plugin answers it late) still pending on the wire, and only one
+// goroutine may ever call bufio.Scanner.Scan on the same Scanner at a time.
+// Read discards any response whose id doesn't match the request it's
+// currently waiting on, which resynchronizes the stream after a late
+// response to an abandoned request arrives.
+type ExecProvider struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+
+	mu sync.Mutex
+
+	readOnce sync.Once
+	results  chan execReaderResult
+	readErr  error // set once, before results is closed; guarded by the close of results happening-before any receive observing it closed
+	stopped  chan struct{}
+
+	closeOnce sync.Once
+}
+
+// NewExecProvider starts the executable at path and returns an ExecProvider
+// ready to serve Read calls. Callers must call Close when done with it.
+func NewExecProvider(path string) (*ExecProvider, error) {
+	cmd := exec.Command(path)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin for provider plugin %s: %w", path, err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout for provider plugin %s: %w", path, err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start provider plugin %s: %w", path, err)
+	}
+
+	p := &ExecProvider{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewScanner(stdout),
+	}
+	p.startReadLoop()
+	return p, nil
+}
+
+// startReadLoop lazily starts readLoop. It's idempotent so it's safe to call
+// from both NewExecProvider and Read (tests construct an ExecProvider
+// directly without going through NewExecProvider).
+func (p *ExecProvider) startReadLoop() {
+	p.readOnce.Do(func() {
+		p.results = make(chan execReaderResult)
+		p.stopped = make(chan struct{})
+		go p.readLoop()
+	})
+}
+
+// readLoop is the only goroutine that ever calls p.stdout.Scan, for as long
+// as the provider lives. It forwards each decoded line to results, giving up
+// on a pending send once Close has signaled stopped so a plugin that keeps
+// chattering after Close can't leak this goroutine forever.
+func (p *ExecProvider) readLoop() {
+	for p.stdout.Scan() {
+		var result execReaderResult
+		if err := json.Unmarshal(p.stdout.Bytes(), &result.resp); err != nil {
+			result.err = err
+		}
+
+		select {
+		case p.results <- result:
+		case <-p.stopped:
+			return
+		}
+	}
+
+	p.readErr = p.stdout.Err()
+	if p.readErr == nil {
+		p.readErr = io.EOF
+	}
+	close(p.results)
+}
+
+// Read sends a single request to the plugin and waits up to timeout for its
+// matching response. If the plugin crashes, writes garbage, or closes its
+// output, a SecretVal carrying an error is returned instead.
+func (p *ExecProvider) Read(id string, params map[string]string, timeout time.Duration) secrets.SecretVal {
+	p.startReadLoop()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	req, err := json.Marshal(execRequest{ID: id, Params: params})
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("failed to marshal request for %q: %v", id, err)}
+	}
+
+	if _, err := p.stdin.Write(append(req, '\n')); err != nil {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("failed to write request for %q to plugin: %v", id, err)}
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			return secrets.SecretVal{ErrorMsg: fmt.Sprintf("timed out after %s waiting for plugin response to %q", timeout, id)}
+		case r, ok := <-p.results:
+			if !ok {
+				return secrets.SecretVal{ErrorMsg: fmt.Sprintf("plugin closed its output before answering %q: %v", id, p.readErr)}
+			}
+			if r.err != nil {
+				// A malformed line can't be matched to any request by id.
+				// It may belong to an earlier request this call's caller
+				// already gave up on; discard it and keep waiting for our
+				// own response instead of failing this call on someone
+				// else's error.
+				continue
+			}
+			if r.resp.ID != id {
+				// A late response to a request a previous, already
+				// timed-out Read call gave up on. Discard it and keep
+				// waiting for ours so the stream doesn't permanently
+				// desync.
+				continue
+			}
+			if r.resp.Error != "" {
+				return secrets.SecretVal{ErrorMsg: r.resp.Error}
+			}
+			return secrets.SecretVal{Value: r.resp.Value}
+		}
+	}
+}
+
+// closeWait is how long Close waits for the plugin to exit on its own after
+// its stdin is closed before it gives up and kills the process.
+const closeWait = 5 * time.Second
+
+// Close terminates the plugin process. It closes stdin to give the plugin a
+// chance to exit on its own, but doesn't trust it to: a plugin that ignores
+// stdin closing (or hangs for any other reason) is killed after closeWait
+// rather than blocking the caller indefinitely.
+func (p *ExecProvider) Close() error {
+	p.startReadLoop()
+	p.closeOnce.Do(func() { close(p.stopped) })
+	p.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- p.cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(closeWait):
+		if err := p.cmd.Process.Kill(); err != nil {
+			return fmt.Errorf("failed to kill unresponsive provider plugin: %w", err)
+		}
+		return <-done
+	}
+}
+
+// ParseExecProviderPath extracts the executable path out of an
+// "exec://path/to/binary" provider URI.
+func ParseExecProviderPath(uri string) (string, bool) {
+	if !strings.HasPrefix(uri, ExecProviderPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(uri, ExecProviderPrefix), true
+}
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHelperProcess is not a real test. It is re-executed as a subprocess by
+// the tests below (following the standard os/exec testing pattern) to act as
+// a fake provider plugin speaking the line-delimited JSON protocol.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	switch os.Getenv("GO_HELPER_PROCESS_MODE") {
+	case "crash":
+		os.Exit(1)
+	case "hang":
+		time.Sleep(time.Hour)
+	case "garbage":
+		fmt.Println("not json")
+	case "slow":
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			var req execRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				continue
+			}
+
+			time.Sleep(150 * time.Millisecond)
+			resp := execResponse{ID: req.ID, Value: "value-for-" + req.Params["key"]}
+			out, _ := json.Marshal(resp)
+			fmt.Println(string(out))
+		}
+	default:
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			var req execRequest
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				continue
+			}
+
+			resp := execResponse{ID: req.ID, Value: "value-for-" + req.Params["key"]}
+			out, _ := json.Marshal(resp)
+			fmt.Println(string(out))
+		}
+	}
+}
+
+func helperProviderCommand(mode string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1", "GO_HELPER_PROCESS_MODE="+mode)
+	return cmd
+}
+
+func newTestExecProvider(t *testing.T, mode string) *ExecProvider {
+	t.Helper()
+	cmd := helperProviderCommand(mode)
+
+	stdin, err := cmd.StdinPipe()
+	require.NoError(t, err)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+
+	p := &ExecProvider{cmd: cmd, stdin: stdin, stdout: bufio.NewScanner(stdout)}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func TestExecProviderRead(t *testing.T) {
+	p := newTestExecProvider(t, "")
+
+	val := p.Read("my_secret", map[string]string{"key": "db_password"}, time.Second)
+	assert.Empty(t, val.ErrorMsg)
+	assert.Equal(t, "value-for-db_password", val.Value)
+}
+
+func TestExecProviderReadCrash(t *testing.T) {
+	p := newTestExecProvider(t, "crash")
+
+	val := p.Read("my_secret", nil, time.Second)
+	assert.Empty(t, val.Value)
+	assert.NotEmpty(t, val.ErrorMsg)
+}
+
+func TestExecProviderReadGarbage(t *testing.T) {
+	p := newTestExecProvider(t, "garbage")
+
+	val := p.Read("my_secret", nil, time.Second)
+	assert.Empty(t, val.Value)
+	assert.NotEmpty(t, val.ErrorMsg)
+}
+
+func TestExecProviderReadTimeout(t *testing.T) {
+	p := newTestExecProvider(t, "hang")
+
+	val := p.Read("my_secret", nil, 50*time.Millisecond)
+	assert.Empty(t, val.Value)
+	assert.Contains(t, val.ErrorMsg, "timed out")
+}
+
+// TestExecProviderReadLateResponseAfterTimeout covers a plugin that answers
+// a request only after its caller has already given up: the late response
+// must not be mistaken for the answer to a subsequent, unrelated request.
+func TestExecProviderReadLateResponseAfterTimeout(t *testing.T) {
+	p := newTestExecProvider(t, "slow")
+
+	val := p.Read("abandoned", map[string]string{"key": "first"}, 10*time.Millisecond)
+	assert.Empty(t, val.Value)
+	assert.Contains(t, val.ErrorMsg, "timed out")
+
+	// The plugin's late response to "abandoned" is still in flight; this
+	// call must wait for its own response rather than being handed that
+	// stale one.
+	val = p.Read("second", map[string]string{"key": "second"}, time.Second)
+	assert.Empty(t, val.ErrorMsg)
+	assert.Equal(t, "value-for-second", val.Value)
+}
+
+func TestParseExecProviderPath(t *testing.T) {
+	path, ok := ParseExecProviderPath("exec:///usr/local/bin/my-provider")
+	assert.True(t, ok)
+	assert.Equal(t, "/usr/local/bin/my-provider", path)
+
+	_, ok = ParseExecProviderPath("/usr/local/bin/my-provider")
+	assert.False(t, ok)
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// ReadHelmReleaseSecret reads a value out of a Helm release manifest stored
+// in a Kubernetes secret. id is expected to be in the
+// "namespace/release/version#jsonpath" format. The release data is expected
+// to be stored, as Helm itself stores it, gzip-compressed then base64-encoded
+// under the "release" key of a "sh.helm.release.v1.<release>.v<version>"
+// secret.
+func ReadHelmReleaseSecret(getter KubeSecretGetter, id string) secrets.SecretVal {
+	namespace, release, version, path, err := splitHelmReleaseID(id)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	secretName := fmt.Sprintf("sh.helm.release.v1.%s.v%s", release, version)
+	data, err := getter(namespace, secretName)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	encoded, ok := data["release"]
+	if !ok {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf(`key "release" not found in secret %s/%s`, namespace, secretName)}
+	}
+
+	manifest, err := decodeHelmRelease(encoded)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("failed to decode helm release %s/%s: %v", namespace, secretName, err)}
+	}
+
+	value, err := evalJSONPath(manifest, path)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("failed to evaluate jsonpath %q: %v", path, err)}
+	}
+
+	return secrets.SecretVal{Value: value}
+}
+
+// decodeHelmRelease reverses the base64 -> gzip -> JSON encoding pipeline
+// Helm uses to store release manifests in secrets, returning the decoded
+// JSON document.
+func decodeHelmRelease(encoded []byte) (map[string]interface{}, error) {
+	gzipped := make([]byte, base64.StdEncoding.DecodedLen(len(encoded)))
+	n, err := base64.StdEncoding.Decode(gzipped, encoded)
+	if err != nil {
+		return nil, fmt.Errorf("base64 decode: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(gzipped[:n]))
+	if err != nil {
+		return nil, fmt.Errorf("gzip decode: %w", err)
+	}
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("gzip decode: %w", err)
+	}
+
+	var manifest map[string]interface{}
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("json decode: %w", err)
+	}
+
+	return manifest, nil
+}
+
+func evalJSONPath(manifest map[string]interface{}, path string) (string, error) {
+	jp := jsonpath.New("helm_release")
+	if err := jp.Parse(fmt.Sprintf("{.%s}", path)); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := jp.Execute(&buf, manifest); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+func splitHelmReleaseID(id string) (namespace string, release string, version string, path string, err error) {
+	idAndPath := strings.SplitN(id, "#", 2)
+	if len(idAndPath) != 2 || idAndPath[1] == "" {
+		return "", "", "", "", fmt.Errorf("invalid helm_release id %q, expected namespace/release/version#jsonpath", id)
+	}
+
+	parts := strings.SplitN(idAndPath[0], "/", 3)
+	if len(parts) != 3 {
+		return "", "", "", "", fmt.Errorf("invalid helm_release id %q, expected namespace/release/version#jsonpath", id)
+	}
+
+	return parts[0], parts[1], parts[2], idAndPath[1], nil
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeHelmReleaseFixture(t *testing.T, rawManifest string) []byte {
+	t.Helper()
+
+	var gzipped bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	_, err := w.Write([]byte(rawManifest))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(gzipped.Len()))
+	base64.StdEncoding.Encode(encoded, gzipped.Bytes())
+	return encoded
+}
+
+func TestReadHelmReleaseSecret(t *testing.T) {
+	encoded := encodeHelmReleaseFixture(t, `{"config":{"dbPassword":"hunter2"}}`)
+
+	getter := func(namespace, name string) (map[string][]byte, error) {
+		assert.Equal(t, "some_namespace", namespace)
+		assert.Equal(t, "sh.helm.release.v1.myrelease.v3", name)
+		return map[string][]byte{"release": encoded}, nil
+	}
+
+	val := ReadHelmReleaseSecret(getter, "some_namespace/myrelease/3#config.dbPassword")
+	assert.Equal(t, "hunter2", val.Value)
+	assert.Empty(t, val.ErrorMsg)
+}
+
+func TestReadHelmReleaseSecretMissingKey(t *testing.T) {
+	encoded := encodeHelmReleaseFixture(t, `{"config":{"dbPassword":"hunter2"}}`)
+
+	getter := func(_ string, _ string) (map[string][]byte, error) {
+		return map[string][]byte{"release": encoded}, nil
+	}
+
+	val := ReadHelmReleaseSecret(getter, "some_namespace/myrelease/3#config.missing")
+	assert.Empty(t, val.Value)
+	assert.NotEmpty(t, val.ErrorMsg)
+}
+
+func TestSplitHelmReleaseID(t *testing.T) {
+	namespace, release, version, path, err := splitHelmReleaseID("some_namespace/myrelease/3#config.dbPassword")
+	require.NoError(t, err)
+	assert.Equal(t, "some_namespace", namespace)
+	assert.Equal(t, "myrelease", release)
+	assert.Equal(t, "3", version)
+	assert.Equal(t, "config.dbPassword", path)
+
+	_, _, _, _, err = splitHelmReleaseID("invalid")
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package providers implements the secret providers supported by the
+// secrethelper subcommand when invoked with "--with-provider-prefixes".
+package providers
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// KubeSecretGetter fetches the data of a Kubernetes secret given its
+// namespace and name.
+type KubeSecretGetter func(namespace string, name string) (map[string][]byte, error)
+
+// KubeSecretLister lists the Kubernetes secrets matching a label selector in
+// a given namespace, returning their name alongside their data.
+type KubeSecretLister func(namespace string, labelSelector string) (map[string]map[string][]byte, error)
+
+// ReadSecretFile reads a secret from a file on disk.
+func ReadSecretFile(path string) secrets.SecretVal {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets.SecretVal{ErrorMsg: "secret does not exist"}
+		}
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	return secrets.SecretVal{Value: string(content)}
+}
+
+// ReadKubernetesSecret reads a secret from a Kubernetes secret object. id is
+// expected to be in the "namespace/name/key" format.
+func ReadKubernetesSecret(getter KubeSecretGetter, id string) secrets.SecretVal {
+	namespace, name, key, err := splitKubeSecretID(id)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	data, err := getter(namespace, name)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	value, ok := data[key]
+	if !ok {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("key %q not found in secret %s/%s", key, namespace, name)}
+	}
+
+	return secrets.SecretVal{Value: string(value)}
+}
+
+// ReadKubernetesSecretByLabel reads a secret from a Kubernetes secret object
+// selected by a label, rather than by name. id is expected to be in the
+// "namespace/label.key=label.value/dataKey" format. It is an error for the
+// label selector to match zero or more than one secret.
+func ReadKubernetesSecretByLabel(lister KubeSecretLister, id string) secrets.SecretVal {
+	namespace, labelSelector, key, err := splitKubeSecretLabelID(id)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	matches, err := lister(namespace, labelSelector)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	switch len(matches) {
+	case 0:
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("no secret found in namespace %q matching label %q", namespace, labelSelector)}
+	case 1:
+		for name, data := range matches {
+			value, ok := data[key]
+			if !ok {
+				return secrets.SecretVal{ErrorMsg: fmt.Sprintf("key %q not found in secret %s/%s", key, namespace, name)}
+			}
+			return secrets.SecretVal{Value: string(value)}
+		}
+	}
+
+	names := make([]string, 0, len(matches))
+	for name := range matches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return secrets.SecretVal{ErrorMsg: fmt.Sprintf("more than one secret in namespace %q matches label %q: %s", namespace, labelSelector, strings.Join(names, ", "))}
+}
+
+// splitKubeSecretLabelID parses "namespace/labelSelector/dataKey". It can't
+// use a flat 3-way split on "/": a domain-prefixed label key like
+// "app.kubernetes.io/name=foo" contains a "/" of its own, so only the first
+// "/" (ending namespace) and the last "/" (starting dataKey) are
+// structural; everything between them belongs to the label selector.
+func splitKubeSecretLabelID(id string) (namespace string, labelSelector string, key string, err error) {
+	invalid := fmt.Errorf("invalid k8s_secret_label id %q, expected namespace/label.key=label.value/dataKey", id)
+
+	firstSlash := strings.Index(id, "/")
+	if firstSlash < 0 {
+		return "", "", "", invalid
+	}
+	lastSlash := strings.LastIndex(id, "/")
+	if lastSlash == firstSlash {
+		return "", "", "", invalid
+	}
+
+	namespace = id[:firstSlash]
+	labelSelector = id[firstSlash+1 : lastSlash]
+	key = id[lastSlash+1:]
+	if namespace == "" || labelSelector == "" || key == "" {
+		return "", "", "", invalid
+	}
+
+	return namespace, labelSelector, key, nil
+}
+
+func splitKubeSecretID(id string) (namespace string, name string, key string, err error) {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("invalid k8s_secret id %q, expected namespace/name/key", id)
+	}
+
+	return parts[0], parts[1], parts[2], nil
+}
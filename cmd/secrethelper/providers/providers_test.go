@@ -0,0 +1,36 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitKubeSecretLabelID(t *testing.T) {
+	namespace, labelSelector, key, err := splitKubeSecretLabelID("default/env=prod/password")
+	require.NoError(t, err)
+	assert.Equal(t, "default", namespace)
+	assert.Equal(t, "env=prod", labelSelector)
+	assert.Equal(t, "password", key)
+}
+
+func TestSplitKubeSecretLabelIDDomainPrefixedLabelKey(t *testing.T) {
+	namespace, labelSelector, key, err := splitKubeSecretLabelID("default/app.kubernetes.io/name=foo/password")
+	require.NoError(t, err)
+	assert.Equal(t, "default", namespace)
+	assert.Equal(t, "app.kubernetes.io/name=foo", labelSelector)
+	assert.Equal(t, "password", key)
+}
+
+func TestSplitKubeSecretLabelIDInvalid(t *testing.T) {
+	for _, id := range []string{"", "default", "default/env=prod"} {
+		_, _, _, err := splitKubeSecretLabelID(id)
+		assert.Error(t, err, "id %q should be rejected", id)
+	}
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/getsops/sops/v3/decrypt"
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// ReadSOPSSecret reads a value out of a SOPS-encrypted YAML or JSON file. id
+// is expected to be in the "/path/to/file.enc.yaml#dotted.key.path" format.
+// Decryption keys are picked up by the sops library itself from the usual
+// environment variables (SOPS_AGE_KEY_FILE, SOPS_GPG_KEYRING, the KMS/GCP/Azure
+// credentials, ...), so no key material needs to be threaded through here.
+func ReadSOPSSecret(id string) secrets.SecretVal {
+	path, keyPath, err := splitSOPSID(id)
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: err.Error()}
+	}
+
+	cleartext, err := decrypt.File(path, sopsFormat(path))
+	if err != nil {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("failed to decrypt %s: %v", path, err)}
+	}
+
+	var contents map[string]interface{}
+	if err := yaml.Unmarshal(cleartext, &contents); err != nil {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("failed to parse decrypted %s: %v", path, err)}
+	}
+
+	value, ok := lookupDottedKey(contents, strings.Split(keyPath, "."))
+	if !ok {
+		return secrets.SecretVal{ErrorMsg: fmt.Sprintf("key %q not found in %s", keyPath, path)}
+	}
+
+	return secrets.SecretVal{Value: fmt.Sprintf("%v", value)}
+}
+
+func splitSOPSID(id string) (path string, keyPath string, err error) {
+	parts := strings.SplitN(id, "#", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid sops id %q, expected /path/to/file#dotted.key.path", id)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// sopsFormat maps a file extension to the format name expected by the sops
+// decrypt package. JSON files are decrypted as "json", everything else is
+// assumed to be YAML, which is the format SOPS itself defaults to.
+func sopsFormat(path string) string {
+	if filepath.Ext(path) == ".json" {
+		return "json"
+	}
+	return "yaml"
+}
+
+func lookupDottedKey(contents map[string]interface{}, keys []string) (interface{}, bool) {
+	current := interface{}(contents)
+	for _, key := range keys {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSOPSID(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		path        string
+		keyPath     string
+		expectedErr string
+	}{
+		{
+			name:    "valid id",
+			id:      "/path/to/file.enc.yaml#dotted.key.path",
+			path:    "/path/to/file.enc.yaml",
+			keyPath: "dotted.key.path",
+		},
+		{
+			name:        "missing key path",
+			id:          "/path/to/file.enc.yaml",
+			expectedErr: `invalid sops id "/path/to/file.enc.yaml", expected /path/to/file#dotted.key.path`,
+		},
+		{
+			name:        "empty key path",
+			id:          "/path/to/file.enc.yaml#",
+			expectedErr: `invalid sops id "/path/to/file.enc.yaml#", expected /path/to/file#dotted.key.path`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, keyPath, err := splitSOPSID(tt.id)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.path, path)
+			assert.Equal(t, tt.keyPath, keyPath)
+		})
+	}
+}
+
+func TestLookupDottedKey(t *testing.T) {
+	contents := map[string]interface{}{
+		"dotted": map[string]interface{}{
+			"key": map[string]interface{}{
+				"path": "a-value",
+			},
+		},
+	}
+
+	value, ok := lookupDottedKey(contents, []string{"dotted", "key", "path"})
+	assert.True(t, ok)
+	assert.Equal(t, "a-value", value)
+
+	_, ok = lookupDottedKey(contents, []string{"dotted", "missing"})
+	assert.False(t, ok)
+}
+
+func TestSopsFormat(t *testing.T) {
+	assert.Equal(t, "json", sopsFormat("/path/to/file.enc.json"))
+	assert.Equal(t, "yaml", sopsFormat("/path/to/file.enc.yaml"))
+	assert.Equal(t, "yaml", sopsFormat("/path/to/file"))
+}
@@ -11,10 +11,20 @@
 //
 // 1) With the "--with-provider-prefixes" option enabled. Each input secret
 // should follow this format: "providerPrefix/some/path". The provider prefix
-// indicates where to fetch the secrets from. At the moment, we support "file"
-// and "k8s_secret". The path can mean different things depending on the
-// provider. In "file" it's a file system path. In "k8s_secret", it follows this
-// format: "namespace/name/key".
+// indicates where to fetch the secrets from. At the moment, we support "file",
+// "k8s_secret", "k8s_secret_label", "helm_release" and "sops". The path can mean different
+// things depending on the provider. In "file" it's a file system path. In
+// "k8s_secret", it follows this format: "namespace/name/key". In
+// "k8s_secret_label", it follows this format:
+// "namespace/label.key=label.value/dataKey" and looks up the secret by label
+// instead of by name, erroring out if zero or more than one secret matches.
+// In "helm_release", it follows this format:
+// "namespace/release/version#jsonpath" and reads the value at the given
+// JSONPath out of the Helm release manifest stored by Helm itself in the
+// "sh.helm.release.v1.<release>.v<version>" secret. In "sops", it follows
+// this format: "/path/to/file.enc.yaml#dotted.key.path", where the file is
+// decrypted in-process using whichever age/GPG/KMS keys are configured in the
+// environment.
 //
 // 2) Without the "--with-provider-prefixes" option. The program expects a root
 // path in the arguments and input secrets are just paths relative to the root
@@ -32,6 +42,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -44,14 +55,23 @@ import (
 
 const (
 	providerPrefixesFlag    = "with-provider-prefixes"
+	cacheDirFlag            = "cache-dir"
+	cacheTTLFlag            = "cache-ttl"
+	auditLogFlag            = "audit-log"
 	providerPrefixSeparator = "@"
 	filePrefix              = "file"
 	k8sSecretPrefix         = "k8s_secret"
+	k8sSecretLabelPrefix    = "k8s_secret_label"
+	helmReleasePrefix       = "helm_release"
+	sopsPrefix              = "sops"
 )
 
 // cliParams are the command-line arguments for this subcommand
 type cliParams struct {
 	usePrefixes bool
+	cacheDir    string
+	cacheTTL    time.Duration
+	auditLog    string
 
 	// args are the positional command-line arguments
 	args []string
@@ -72,7 +92,10 @@ func Commands() []*cobra.Command {
 			)
 		},
 	}
-	cmd.PersistentFlags().BoolVarP(&cliParams.usePrefixes, providerPrefixesFlag, "", false, "Use prefixes to select the secrets provider (file, k8s_secret)")
+	cmd.PersistentFlags().BoolVarP(&cliParams.usePrefixes, providerPrefixesFlag, "", false, "Use prefixes to select the secrets provider (file, k8s_secret, k8s_secret_label, helm_release, sops)")
+	cmd.PersistentFlags().StringVarP(&cliParams.cacheDir, cacheDirFlag, "", "", "Directory used to persist fetched secrets across invocations of this command (disabled by default)")
+	cmd.PersistentFlags().DurationVarP(&cliParams.cacheTTL, cacheTTLFlag, "", defaultCacheTTL, "How long a fetched secret may be served from cache before being re-fetched")
+	cmd.PersistentFlags().StringVarP(&cliParams.auditLog, auditLogFlag, "", "", "File to append structured audit entries to, in addition to stderr (disabled by default)")
 
 	secretHelperCmd := &cobra.Command{
 		Use:   "secret-helper",
@@ -89,40 +112,64 @@ type secretsRequest struct {
 	Secrets []string `json:"secrets"`
 }
 
+// payloadVersion is used to peek at the "version" field of a request before
+// deciding which payload shape to unmarshal the rest of it into.
+type payloadVersion struct {
+	Version string `json:"version"`
+}
+
 func readCmd(cliParams *cliParams) error {
 	dir := ""
 	if len(cliParams.args) == 1 {
 		dir = cliParams.args[0]
 	}
 
-	return readSecrets(os.Stdin, os.Stdout, dir, cliParams.usePrefixes, apiserver.GetKubeSecret)
-}
-
-func readSecrets(r io.Reader, w io.Writer, dir string, usePrefixes bool, kubeSecretGetter providers.KubeSecretGetter) error {
-	inputSecrets, err := parseInputSecrets(r)
+	closer, err := configureAuditLog(cliParams.auditLog)
 	if err != nil {
 		return err
 	}
+	defer closer.Close()
 
-	if usePrefixes {
-		return writeFetchedSecrets(w, readSecretsUsingPrefixes(inputSecrets, dir, kubeSecretGetter))
-	}
-
-	return writeFetchedSecrets(w, readSecretsFromFile(inputSecrets, dir))
+	return readSecrets(os.Stdin, os.Stdout, dir, cliParams.usePrefixes, cliParams.cacheDir, cliParams.cacheTTL, apiserver.GetKubeSecret, apiserver.ListKubeSecretsByLabel)
 }
 
-func parseInputSecrets(r io.Reader) ([]string, error) {
+func readSecrets(r io.Reader, w io.Writer, dir string, usePrefixes bool, cacheDir string, cacheTTL time.Duration, kubeSecretGetter providers.KubeSecretGetter, kubeSecretLister providers.KubeSecretLister) error {
 	in, err := io.ReadAll(r)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	var version payloadVersion
+	if err := json.Unmarshal(in, &version); err != nil {
+		return errors.New("failed to unmarshal json input")
+	}
+
+	// The v2.0 protocol has a fundamentally different payload shape (each
+	// secret carries its own provider and params, instead of being selected
+	// through a "provider@id" prefix), so it's handled by its own code path
+	// rather than being shoehorned into parseInputSecrets/readSecretsUsingPrefixes.
+	if splitVersion(version.Version)[0] == "2" {
+		return readSecretsV2(in, w, cacheDir, kubeSecretGetter)
 	}
 
 	var request secretsRequest
-	err = json.Unmarshal(in, &request)
+	if err := json.Unmarshal(in, &request); err != nil {
+		return errors.New("failed to unmarshal json input")
+	}
+
+	inputSecrets, err := parseInputSecrets(request)
 	if err != nil {
-		return nil, errors.New("failed to unmarshal json input")
+		return err
+	}
+
+	if usePrefixes {
+		return writeFetchedSecrets(w, readSecretsUsingPrefixes(inputSecrets, dir, cacheDir, cacheTTL, kubeSecretGetter, kubeSecretLister))
 	}
 
+	return writeFetchedSecrets(w, readSecretsFromFile(inputSecrets, dir, cacheDir, cacheTTL))
+}
+
+func parseInputSecrets(request secretsRequest) ([]string, error) {
 	version := splitVersion(request.Version)
 	compatVersion := splitVersion(secrets.PayloadVersion)
 	if version[0] != compatVersion[0] {
@@ -146,18 +193,23 @@ func writeFetchedSecrets(w io.Writer, fetchedSecrets map[string]secrets.SecretVa
 	return err
 }
 
-func readSecretsFromFile(secretsList []string, dir string) map[string]secrets.SecretVal {
+func readSecretsFromFile(secretsList []string, dir string, cacheDir string, cacheTTL time.Duration) map[string]secrets.SecretVal {
 	res := make(map[string]secrets.SecretVal)
+	cache := newSecretCache(cacheDir)
 
 	for _, secretID := range secretsList {
-		res[secretID] = providers.ReadSecretFile(filepath.Join(dir, secretID))
+		path := filepath.Join(dir, secretID)
+		res[secretID] = fetchWithCache(cache, filePrefix, path, cacheTTL, func() secrets.SecretVal {
+			return providers.ReadSecretFile(path)
+		})
 	}
 
 	return res
 }
 
-func readSecretsUsingPrefixes(secretsList []string, rootPath string, kubeSecretGetter providers.KubeSecretGetter) map[string]secrets.SecretVal {
+func readSecretsUsingPrefixes(secretsList []string, rootPath string, cacheDir string, cacheTTL time.Duration, kubeSecretGetter providers.KubeSecretGetter, kubeSecretLister providers.KubeSecretLister) map[string]secrets.SecretVal {
 	res := make(map[string]secrets.SecretVal)
+	cache := newSecretCache(cacheDir)
 
 	for _, secretID := range secretsList {
 		prefix, id, err := parseSecretWithPrefix(secretID, rootPath)
@@ -166,14 +218,22 @@ func readSecretsUsingPrefixes(secretsList []string, rootPath string, kubeSecretG
 			continue
 		}
 
-		switch prefix {
-		case filePrefix:
-			res[secretID] = providers.ReadSecretFile(id)
-		case k8sSecretPrefix:
-			res[secretID] = providers.ReadKubernetesSecret(kubeSecretGetter, id)
-		default:
-			res[secretID] = secrets.SecretVal{Value: "", ErrorMsg: fmt.Sprintf("provider not supported: %s", prefix)}
-		}
+		res[secretID] = fetchWithCache(cache, prefix, id, cacheTTL, func() secrets.SecretVal {
+			switch prefix {
+			case filePrefix:
+				return providers.ReadSecretFile(id)
+			case k8sSecretPrefix:
+				return providers.ReadKubernetesSecret(kubeSecretGetter, id)
+			case k8sSecretLabelPrefix:
+				return providers.ReadKubernetesSecretByLabel(kubeSecretLister, id)
+			case helmReleasePrefix:
+				return providers.ReadHelmReleaseSecret(kubeSecretGetter, id)
+			case sopsPrefix:
+				return providers.ReadSOPSSecret(id)
+			default:
+				return secrets.SecretVal{Value: "", ErrorMsg: fmt.Sprintf("provider not supported: %s", prefix)}
+			}
+		})
 	}
 
 	return res
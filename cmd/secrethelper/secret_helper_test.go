@@ -38,6 +38,46 @@ func TestReadSecrets(t *testing.T) {
 		return secret.Data, nil
 	}
 
+	newKubeListFunc := func(namespace, labelSelector string) (map[string]map[string][]byte, error) {
+		kubeClient := fake.NewSimpleClientset(
+			&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "unique_name",
+					Namespace: "some_namespace",
+					Labels:    map[string]string{"role": "unique"},
+				},
+				Data: map[string][]byte{"some_key": []byte("unique_value")},
+			},
+			&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "labeled_name",
+					Namespace: "some_namespace",
+					Labels:    map[string]string{"app": "myapp"},
+				},
+				Data: map[string][]byte{"some_key": []byte("labeled_value")},
+			},
+			&v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "other_labeled_name",
+					Namespace: "some_namespace",
+					Labels:    map[string]string{"app": "myapp"},
+				},
+				Data: map[string][]byte{"some_key": []byte("other_labeled_value")},
+			},
+		)
+
+		list, err := kubeClient.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+
+		res := make(map[string]map[string][]byte, len(list.Items))
+		for _, item := range list.Items {
+			res[item.Name] = item.Data
+		}
+		return res, nil
+	}
+
 	tests := []struct {
 		name        string
 		in          string
@@ -55,11 +95,61 @@ func TestReadSecrets(t *testing.T) {
 			name: "invalid version",
 			in: `
 			{
-				"version": "2.0"
+				"version": "3.0"
+			}
+			`,
+			out: "",
+			err: `incompatible protocol version "3.0"`,
+		},
+		{
+			name: "v2 payload, no secrets",
+			in: `
+			{
+				"version": "2.0",
+				"secrets": []
 			}
 			`,
 			out: "",
-			err: `incompatible protocol version "2.0"`,
+			err: `no secrets listed in input`,
+		},
+		{
+			name: "v2 payload, built-in file and k8s_secret providers",
+			in: fmt.Sprintf(`
+			{
+				"version": "2.0",
+				"secrets": [
+					{"id": "secret1", "provider": "file", "params": {"path": %q}},
+					{"id": "secret2", "provider": "k8s_secret", "params": {"namespace": "some_namespace", "name": "some_name", "key": "some_key"}}
+				]
+			}`, secretAbsPath("secret1")),
+			out: `
+			{
+				"secret1": {
+					"value": "secret1-value"
+				},
+				"secret2": {
+					"value": "some_value"
+				}
+			}
+			`,
+		},
+		{
+			name: "v2 payload, unknown provider",
+			in: `
+			{
+				"version": "2.0",
+				"secrets": [
+					{"id": "secret1", "provider": "vault"}
+				]
+			}
+			`,
+			out: `
+			{
+				"secret1": {
+					"error": "provider not supported: vault"
+				}
+			}
+			`,
 		},
 		{
 			name: "no secrets",
@@ -159,13 +249,70 @@ func TestReadSecrets(t *testing.T) {
 			`,
 			usePrefixes: true,
 		},
+		{
+			name: "k8s_secret_label prefix, exactly one match",
+			in: `
+			{
+				"version": "1.0",
+				"secrets": [
+					"k8s_secret_label@some_namespace/role=unique/some_key"
+				]
+			}
+			`,
+			out: `
+			{
+				"k8s_secret_label@some_namespace/role=unique/some_key": {
+					"value": "unique_value"
+				}
+			}
+			`,
+			usePrefixes: true,
+		},
+		{
+			name: "k8s_secret_label prefix, no match",
+			in: `
+			{
+				"version": "1.0",
+				"secrets": [
+					"k8s_secret_label@some_namespace/role=missing/some_key"
+				]
+			}
+			`,
+			out: `
+			{
+				"k8s_secret_label@some_namespace/role=missing/some_key": {
+					"error": "no secret found in namespace \"some_namespace\" matching label \"role=missing\""
+				}
+			}
+			`,
+			usePrefixes: true,
+		},
+		{
+			name: "k8s_secret_label prefix, multiple matches",
+			in: `
+			{
+				"version": "1.0",
+				"secrets": [
+					"k8s_secret_label@some_namespace/app=myapp/some_key"
+				]
+			}
+			`,
+			out: `
+			{
+				"k8s_secret_label@some_namespace/app=myapp/some_key": {
+					"error": "more than one secret in namespace \"some_namespace\" matches label \"app=myapp\": labeled_name, other_labeled_name"
+				}
+			}
+			`,
+			usePrefixes: true,
+		},
 	}
 
 	path := filepath.Join("testdata", "read-secrets")
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			var w bytes.Buffer
-			err := readSecrets(strings.NewReader(test.in), &w, path, test.usePrefixes, newKubeClientFunc)
+			err := readSecrets(strings.NewReader(test.in), &w, path, test.usePrefixes, "", defaultCacheTTL, newKubeClientFunc, newKubeListFunc)
 			out := w.String()
 
 			if test.out != "" {
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrethelper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/DataDog/datadog-agent/cmd/secrethelper/providers"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// execProviderTimeout bounds how long we wait for a single exec provider
+// plugin to answer one request.
+const execProviderTimeout = 5 * time.Second
+
+// payloadV2 is the v2.0 request payload. Unlike v1, where the provider is
+// selected through a "provider@id" prefix on the secret name, each secret
+// carries its own provider name and free-form params, and providers can be
+// resolved to external plugin executables (declared in "providers") rather
+// than being hardcoded into the binary. This lets users add integrations
+// such as Vault or a cloud secrets manager without recompiling the Agent.
+type payloadV2 struct {
+	Version   string            `json:"version"`
+	Secrets   []secretRequestV2 `json:"secrets"`
+	Providers map[string]string `json:"providers"`
+}
+
+// secretRequestV2 is a single secret request in a v2.0 payload.
+type secretRequestV2 struct {
+	ID              string            `json:"id"`
+	Provider        string            `json:"provider"`
+	Params          map[string]string `json:"params,omitempty"`
+	CacheTTLSeconds int               `json:"cache_ttl_seconds,omitempty"`
+}
+
+// readSecretsV2 handles a v2.0 payload. Built-in providers ("file",
+// "k8s_secret") are served in-process; anything else must be declared in the
+// payload's "providers" section as an "exec://path/to/binary" plugin, which
+// is started once and reused for every secret it's asked to resolve.
+func readSecretsV2(in []byte, w io.Writer, cacheDir string, kubeSecretGetter providers.KubeSecretGetter) error {
+	var payload payloadV2
+	if err := json.Unmarshal(in, &payload); err != nil {
+		return errors.New("failed to unmarshal json input")
+	}
+
+	if len(payload.Secrets) == 0 {
+		return errors.New("no secrets listed in input")
+	}
+
+	execProviders := make(map[string]*providers.ExecProvider)
+	defer func() {
+		for _, p := range execProviders {
+			p.Close()
+		}
+	}()
+
+	cache := newSecretCache(cacheDir)
+	res := make(map[string]secrets.SecretVal, len(payload.Secrets))
+	for _, s := range payload.Secrets {
+		res[s.ID] = resolveSecretV2(cache, s, payload.Providers, execProviders, kubeSecretGetter)
+	}
+
+	return writeFetchedSecrets(w, res)
+}
+
+func resolveSecretV2(cache *secretCache, s secretRequestV2, providerURIs map[string]string, execProviders map[string]*providers.ExecProvider, kubeSecretGetter providers.KubeSecretGetter) secrets.SecretVal {
+	ttl := defaultCacheTTL
+	if s.CacheTTLSeconds > 0 {
+		ttl = time.Duration(s.CacheTTLSeconds) * time.Second
+	}
+
+	return fetchWithCache(cache, s.Provider, s.ID, ttl, func() secrets.SecretVal {
+		switch s.Provider {
+		case filePrefix:
+			return providers.ReadSecretFile(s.Params["path"])
+		case k8sSecretPrefix:
+			id := fmt.Sprintf("%s/%s/%s", s.Params["namespace"], s.Params["name"], s.Params["key"])
+			return providers.ReadKubernetesSecret(kubeSecretGetter, id)
+		}
+
+		uri, ok := providerURIs[s.Provider]
+		if !ok {
+			return secrets.SecretVal{ErrorMsg: fmt.Sprintf("provider not supported: %s", s.Provider)}
+		}
+
+		path, ok := providers.ParseExecProviderPath(uri)
+		if !ok {
+			return secrets.SecretVal{ErrorMsg: fmt.Sprintf("provider %q has unsupported uri %q", s.Provider, uri)}
+		}
+
+		p, ok := execProviders[s.Provider]
+		if !ok {
+			var err error
+			p, err = providers.NewExecProvider(path)
+			if err != nil {
+				return secrets.SecretVal{ErrorMsg: err.Error()}
+			}
+			execProviders[s.Provider] = p
+		}
+
+		return p.Read(s.ID, s.Params, execProviderTimeout)
+	})
+}
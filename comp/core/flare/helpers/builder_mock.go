@@ -8,13 +8,18 @@
 package helpers
 
 import (
+	"archive/zip"
+	"encoding/json"
 	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 
 	"github.com/DataDog/datadog-agent/comp/core/flare/types"
 )
@@ -104,3 +109,218 @@ func (m *FlareBuilderMock) AssertNoFileExists(paths ...string) bool {
 func (m *FlareBuilderMock) Save() (string, error) {
 	return "", errors.New("Unimplemented")
 }
+
+// AssertJSONFileMatches asserts that a file exists within the flare,
+// unmarshals as JSON, and deep-equals expected. expected is itself
+// round-tripped through json.Marshal/Unmarshal first so callers can pass a
+// typed struct: it is compared through its JSON encoding, so its json tags
+// must name the file's keys, but its Go field types need not match the
+// generic types json.Unmarshal produces (e.g. an int field vs float64).
+func (m *FlareBuilderMock) AssertJSONFileMatches(expected any, paths ...string) {
+	path := m.filePath(paths...)
+
+	if !assert.FileExists(m.t, path) {
+		return
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(m.t, err)
+
+	var actual any
+	require.NoError(m.t, json.Unmarshal(data, &actual), "failed to unmarshal %s as JSON", path)
+
+	expectedBytes, err := json.Marshal(expected)
+	require.NoError(m.t, err)
+	var normalizedExpected any
+	require.NoError(m.t, json.Unmarshal(expectedBytes, &normalizedExpected))
+
+	assert.Equal(m.t, normalizedExpected, actual, "content of file %s does not match expected JSON", path)
+}
+
+// AssertJSONFilePartial asserts that a file exists within the flare,
+// unmarshals as JSON into a generic map, and checks that expected's entries
+// are present with matching values, addressed by dotted key path (e.g.
+// "metadata.version"). Keys not present in expected are ignored.
+func (m *FlareBuilderMock) AssertJSONFilePartial(expected map[string]any, paths ...string) {
+	path := m.filePath(paths...)
+
+	if !assert.FileExists(m.t, path) {
+		return
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(m.t, err)
+
+	var actual map[string]any
+	require.NoError(m.t, json.Unmarshal(data, &actual), "failed to unmarshal %s as JSON", path)
+
+	for dottedKey, want := range expected {
+		got, ok := lookupDottedKey(actual, strings.Split(dottedKey, "."))
+		if !assert.True(m.t, ok, "key %q not found in %s", dottedKey, path) {
+			continue
+		}
+
+		// Round-trip want through JSON too, same as AssertJSONFileMatches,
+		// so e.g. a caller-supplied int compares equal to the float64 that
+		// json.Unmarshal produced for got instead of spuriously failing.
+		wantBytes, err := json.Marshal(want)
+		require.NoError(m.t, err)
+		var normalizedWant any
+		require.NoError(m.t, json.Unmarshal(wantBytes, &normalizedWant))
+
+		assert.Equal(m.t, normalizedWant, got, "value for key %q in %s did not match", dottedKey, path)
+	}
+}
+
+// AssertYAMLFileMatches asserts that a file exists within the flare,
+// unmarshals as YAML, and deep-equals expected. Like AssertJSONFileMatches,
+// expected is round-tripped through yaml.Marshal/Unmarshal first so the
+// comparison is value-based rather than type-based.
+func (m *FlareBuilderMock) AssertYAMLFileMatches(expected any, paths ...string) {
+	path := m.filePath(paths...)
+
+	if !assert.FileExists(m.t, path) {
+		return
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(m.t, err)
+
+	var actual any
+	require.NoError(m.t, yaml.Unmarshal(data, &actual), "failed to unmarshal %s as YAML", path)
+
+	expectedBytes, err := yaml.Marshal(expected)
+	require.NoError(m.t, err)
+	var normalizedExpected any
+	require.NoError(m.t, yaml.Unmarshal(expectedBytes, &normalizedExpected))
+
+	assert.Equal(m.t, normalizedExpected, actual, "content of file %s does not match expected YAML", path)
+}
+
+// AssertFileScrubbed asserts that a file exists within the flare and that
+// none of secretSubstrings survived in its content, catching providers that
+// forgot to run their output through the scrubber.
+func (m *FlareBuilderMock) AssertFileScrubbed(secretSubstrings []string, paths ...string) {
+	path := m.filePath(paths...)
+
+	if !assert.FileExists(m.t, path) {
+		return
+	}
+	data, err := os.ReadFile(path)
+	require.NoError(m.t, err)
+
+	for _, secret := range secretSubstrings {
+		assert.NotContains(m.t, string(data), secret, "file %s contains unscrubbed secret", path)
+	}
+}
+
+// lookupDottedKey walks nested maps (as produced by json.Unmarshal into
+// map[string]any) following keys, used by AssertJSONFilePartial to
+// address a value by dotted path like "metadata.version".
+func lookupDottedKey(contents map[string]any, keys []string) (any, bool) {
+	value, ok := contents[keys[0]]
+	if !ok {
+		return nil, false
+	}
+	if len(keys) == 1 {
+		return value, true
+	}
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	return lookupDottedKey(nested, keys[1:])
+}
+
+// FlareArchiveWalker iterates the entries of a finalized flare archive.
+type FlareArchiveWalker struct {
+	t       *testing.T
+	entries []*zip.File
+}
+
+// AssertArchive finalizes the in-memory flare into a zip archive (bypassing
+// Save's no-op) and returns a walker over its entries, verifying along the
+// way that no entry escaped the archive root via a symlink or an
+// absolute/traversal path. It doesn't assert on which files the archive
+// contains: that depends entirely on what the provider under test wrote, so
+// callers should follow up with walker.Has/Content for whatever their own
+// provider is expected to produce.
+func (m *FlareBuilderMock) AssertArchive() *FlareArchiveWalker {
+	archivePath := filepath.Join(m.t.TempDir(), "flare.zip")
+
+	archive, err := os.Create(archivePath)
+	require.NoError(m.t, err)
+	defer archive.Close()
+
+	zw := zip.NewWriter(archive)
+	err = filepath.Walk(m.Root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		rel, err := filepath.Rel(m.Root, path)
+		if err != nil {
+			return err
+		}
+		w, err := zw.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+	require.NoError(m.t, err)
+	require.NoError(m.t, zw.Close())
+
+	reader, err := zip.OpenReader(archivePath)
+	require.NoError(m.t, err)
+	m.t.Cleanup(func() { reader.Close() })
+
+	walker := &FlareArchiveWalker{t: m.t, entries: reader.File}
+
+	for _, f := range reader.File {
+		assert.False(m.t, filepath.IsAbs(f.Name), "archive entry %q is an absolute path", f.Name)
+		assert.False(m.t, strings.Contains(f.Name, ".."), "archive entry %q escapes the archive root", f.Name)
+	}
+
+	return walker
+}
+
+// Has reports whether the archive contains an entry at path.
+func (w *FlareArchiveWalker) Has(path string) bool {
+	for _, f := range w.entries {
+		if f.Name == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Content returns the decompressed content of the entry at path.
+func (w *FlareArchiveWalker) Content(path string) ([]byte, error) {
+	for _, f := range w.entries {
+		if f.Name != path {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, os.ErrNotExist
+}
+
+// Entries returns the archive's entry paths, for tests that want to assert
+// on the overall file set.
+func (w *FlareArchiveWalker) Entries() []string {
+	names := make([]string, len(w.entries))
+	for i, f := range w.entries {
+		names[i] = f.Name
+	}
+	return names
+}
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package secrets defines the interface and types shared between the agent
+// and the external secret backends it invokes to resolve "ENC[...]" handles.
+package secrets
+
+// PayloadVersion is the version of the protocol used to communicate with
+// secret backends. Backends should only reject a request if the major
+// version differs from the one they support.
+const PayloadVersion = "1.0"
+
+// SecretVal contains the value of a secret fetched from a backend, or the
+// error encountered while fetching it. Only one of the two fields should be
+// set.
+type SecretVal struct {
+	Value    string `json:"value,omitempty"`
+	ErrorMsg string `json:"error,omitempty"`
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// runningAgentDescriptorSchemaVersion is bumped whenever the descriptor's
+// fields change in a way that isn't purely additive, so an older CLI
+// talking to a newer agent (or vice versa) can tell it doesn't fully
+// understand the file instead of misinterpreting it.
+const runningAgentDescriptorSchemaVersion = 1
+
+// RunningAgentDescriptor is the runtime descriptor a running agent writes
+// to disk at startup, letting CLI commands (stop, status, flare, config)
+// discover its actual bound IPC endpoint instead of relying on a possibly
+// stale or differently-scoped config file.
+type RunningAgentDescriptor struct {
+	SchemaVersion int    `json:"schema_version"`
+	IPCAddress    string `json:"ipc_address"`
+	CmdPort       int    `json:"cmd_port"`
+	AuthTokenPath string `json:"auth_token_path"`
+	PID           int    `json:"pid"`
+	Version       string `json:"version"`
+}
+
+// descriptorFileName is the well-known name of the descriptor file within
+// the agent's run directory.
+const descriptorFileName = "agent-runtime.json"
+
+// descriptorPath returns the descriptor file's path given the agent's run
+// directory (typically config's "run_path").
+func descriptorPath(runPath string) string {
+	return filepath.Join(runPath, descriptorFileName)
+}
+
+// WriteRunningAgentDescriptor atomically writes desc to runPath's
+// descriptor file: it's written to a temp file in the same directory and
+// renamed into place, so a concurrent reader never observes a partial
+// write. Call this once at agent startup, after the IPC listener is bound.
+func WriteRunningAgentDescriptor(runPath string, desc RunningAgentDescriptor) error {
+	desc.SchemaVersion = runningAgentDescriptorSchemaVersion
+
+	data, err := json.Marshal(desc)
+	if err != nil {
+		return err
+	}
+
+	path := descriptorPath(runPath)
+	tmp, err := os.CreateTemp(runPath, descriptorFileName+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// RemoveRunningAgentDescriptor removes the descriptor file, so a clean
+// shutdown doesn't leave behind a stale file future discovery calls would
+// mistake for a still-running agent.
+func RemoveRunningAgentDescriptor(runPath string) error {
+	err := os.Remove(descriptorPath(runPath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// DiscoverRunningAgent reads the running agent's descriptor from runPath,
+// returning it if present and of a schema version this CLI understands.
+// Callers should fall back to config-derived IPC address/port when this
+// returns an error, e.g. because there is no descriptor (the agent predates
+// descriptor support) or its schema version is newer than this CLI
+// understands. A descriptor left behind by an agent that was stopped without
+// cleaning up is still returned, even if its PID no longer exists — it's the
+// caller's job to decide whether a stale-looking descriptor is still worth
+// trusting.
+func DiscoverRunningAgent(runPath string) (RunningAgentDescriptor, error) {
+	data, err := os.ReadFile(descriptorPath(runPath))
+	if err != nil {
+		return RunningAgentDescriptor{}, err
+	}
+
+	var desc RunningAgentDescriptor
+	if err := json.Unmarshal(data, &desc); err != nil {
+		return RunningAgentDescriptor{}, fmt.Errorf("malformed agent runtime descriptor: %v", err)
+	}
+
+	if desc.SchemaVersion > runningAgentDescriptorSchemaVersion {
+		return RunningAgentDescriptor{}, fmt.Errorf("agent runtime descriptor schema version %d is newer than this CLI understands (%d); falling back to config", desc.SchemaVersion, runningAgentDescriptorSchemaVersion)
+	}
+
+	return desc, nil
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndDiscoverRunningAgentDescriptor(t *testing.T) {
+	runPath := t.TempDir()
+
+	want := RunningAgentDescriptor{
+		IPCAddress:    "127.0.0.1",
+		CmdPort:       5001,
+		AuthTokenPath: filepath.Join(runPath, "auth_token"),
+		PID:           1234,
+		Version:       "7.99.0",
+	}
+	require.NoError(t, WriteRunningAgentDescriptor(runPath, want))
+
+	got, err := DiscoverRunningAgent(runPath)
+	require.NoError(t, err)
+	want.SchemaVersion = runningAgentDescriptorSchemaVersion
+	assert.Equal(t, want, got)
+}
+
+func TestDiscoverRunningAgentNoDescriptor(t *testing.T) {
+	_, err := DiscoverRunningAgent(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestDiscoverRunningAgentNewerSchemaVersionFallsBack(t *testing.T) {
+	runPath := t.TempDir()
+	require.NoError(t, WriteRunningAgentDescriptor(runPath, RunningAgentDescriptor{PID: 1}))
+
+	path := descriptorPath(runPath)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Simulate a future agent version bumping the schema.
+	data = []byte(`{"schema_version": 99999, "pid": 1}`)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	_, err = DiscoverRunningAgent(runPath)
+	assert.Error(t, err)
+}
+
+func TestRemoveRunningAgentDescriptorNoFileIsNotAnError(t *testing.T) {
+	assert.NoError(t, RemoveRunningAgentDescriptor(t.TempDir()))
+}
+
+func TestWriteRunningAgentDescriptorIsAtomic(t *testing.T) {
+	runPath := t.TempDir()
+	require.NoError(t, WriteRunningAgentDescriptor(runPath, RunningAgentDescriptor{PID: 1}))
+
+	entries, err := os.ReadDir(runPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temp file should be left behind after a successful write")
+	assert.Equal(t, descriptorFileName, entries[0].Name())
+}
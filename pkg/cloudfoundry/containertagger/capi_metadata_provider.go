@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package containertagger
+
+import (
+	"context"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+// capiMetadataKeys maps the CollectorTags keys that identify a CAPI
+// application (populated by the Cloud Foundry tag collector) to the field
+// name they're surfaced under in the metadata document. Tags outside this
+// set exist for agent-side tagging purposes only and aren't CAPI metadata.
+var capiMetadataKeys = map[string]string{
+	"app_name":           "app_name",
+	"app_id":             "app_id",
+	"app_instance_guid":  "app_instance_guid",
+	"app_instance_index": "app_instance_index",
+	"space_name":         "space_name",
+	"org_name":           "org_name",
+}
+
+// capiMetadataProvider surfaces the CAPI application identity workloadmeta
+// already collected for a container (app name/guid, space, org, ...) as a
+// MetadataProvider document, so it's visible from inside the container at
+// metadataBasePath/capi.json instead of only in the agent's own tag store.
+type capiMetadataProvider struct{}
+
+// Name implements MetadataProvider.
+func (capiMetadataProvider) Name() string {
+	return "capi"
+}
+
+// Collect implements MetadataProvider.
+func (capiMetadataProvider) Collect(_ context.Context, container *workloadmeta.Container) (map[string]interface{}, error) {
+	metadata := make(map[string]interface{})
+
+	for _, tag := range container.CollectorTags {
+		key, value, ok := strings.Cut(tag, ":")
+		if !ok {
+			continue
+		}
+		if field, ok := capiMetadataKeys[key]; ok {
+			metadata[field] = value
+		}
+	}
+
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package containertagger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+func TestCAPIMetadataProviderCollect(t *testing.T) {
+	container := &workloadmeta.Container{
+		CollectorTags: []string{
+			"app_name:my-app",
+			"app_instance_guid:abc-123",
+			"app_instance_index:2",
+			"space_name:development",
+			"org_name:my-org",
+			"container_id:unrelated", // not a CAPI key, must be dropped
+		},
+	}
+
+	metadata, err := capiMetadataProvider{}.Collect(context.Background(), container)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"app_name":           "my-app",
+		"app_instance_guid":  "abc-123",
+		"app_instance_index": "2",
+		"space_name":         "development",
+		"org_name":           "my-org",
+	}, metadata)
+}
+
+func TestCAPIMetadataProviderCollectNoCAPITags(t *testing.T) {
+	container := &workloadmeta.Container{CollectorTags: []string{"container_id:unrelated"}}
+
+	metadata, err := capiMetadataProvider{}.Collect(context.Background(), container)
+	require.NoError(t, err)
+	assert.Nil(t, metadata)
+}
+
+func TestCAPIMetadataProviderName(t *testing.T) {
+	assert.Equal(t, "capi", capiMetadataProvider{}.Name())
+}
@@ -6,7 +6,12 @@
 package containertagger
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -17,21 +22,47 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/cloudproviders/cloudfoundry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta/recovery"
 )
 
 const (
 	componentName = "cloudfoundry-container-tagger"
+
+	// metadataBasePath is the well-known path inside Cloud Foundry
+	// containers where MetadataProvider documents are streamed, one JSON
+	// file per provider, similar to how orchestrator sidecars publish pod
+	// metadata into a container volume.
+	metadataBasePath = "/home/vcap/app/.datadog/metadata"
 )
 
-// ContainerTagger is a simple component that injects host tags and CAPI metadata
-// into cloudfoundry containers. It listens to container collection events from
-// the workloadmeta store and injects tags accordingly if it detects a diff
-// with the previously injected tags.
+// MetadataProvider produces a named metadata document describing a
+// container (labels, annotations, workloadmeta-derived key/values, ...).
+// Providers are registered with RegisterMetadataProvider and are diffed
+// independently of each other, so a change in one provider's output doesn't
+// cause the others to be re-injected.
+type MetadataProvider interface {
+	// Name identifies the provider. It doubles as the file name
+	// (Name()+".json") the document is streamed to under metadataBasePath.
+	Name() string
+
+	// Collect returns the metadata document for container. A nil map skips
+	// injection for this provider on this container.
+	Collect(ctx context.Context, container *workloadmeta.Container) (map[string]interface{}, error)
+}
+
+// ContainerTagger is a simple component that injects host tags, CAPI
+// metadata, and arbitrary MetadataProvider documents into cloudfoundry
+// containers. It listens to container collection events from the
+// workloadmeta store and injects each provider's output if it detects a diff
+// with what was previously injected for that container.
 type ContainerTagger struct {
 	gardenUtil            cloudfoundry.GardenUtilInterface
 	store                 workloadmeta.Store
 	seen                  map[string]struct{}
 	tagsHashByContainerID map[string]string
+
+	metadataProviders           []MetadataProvider
+	metadataHashesByContainerID map[string]map[string]string
 }
 
 // NewContainerTagger creates a new container tagger.
@@ -41,12 +72,22 @@ func NewContainerTagger() (*ContainerTagger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &ContainerTagger{
-		gardenUtil:            gu,
-		store:                 workloadmeta.GetGlobalStore(),
-		seen:                  make(map[string]struct{}),
-		tagsHashByContainerID: make(map[string]string),
-	}, nil
+	ct := &ContainerTagger{
+		gardenUtil:                  gu,
+		store:                       workloadmeta.GetGlobalStore(),
+		seen:                        make(map[string]struct{}),
+		tagsHashByContainerID:       make(map[string]string),
+		metadataHashesByContainerID: make(map[string]map[string]string),
+	}
+	ct.RegisterMetadataProvider(capiMetadataProvider{})
+	return ct, nil
+}
+
+// RegisterMetadataProvider adds a MetadataProvider whose document will be
+// streamed into every container the tagger subsequently sees (e.g. CAPI app
+// metadata, BOSH job info). It is not safe to call concurrently with Start.
+func (c *ContainerTagger) RegisterMetadataProvider(p MetadataProvider) {
+	c.metadataProviders = append(c.metadataProviders, p)
 }
 
 // Start starts the container tagger.
@@ -56,6 +97,10 @@ func (c *ContainerTagger) Start(ctx context.Context) {
 		filter := workloadmeta.NewFilter([]workloadmeta.Kind{workloadmeta.KindContainer}, workloadmeta.SourceClusterOrchestrator, workloadmeta.EventTypeAll)
 		ch := c.store.Subscribe(componentName, workloadmeta.NormalPriority, filter)
 		defer c.store.Unsubscribe(ch)
+		// A panic in processEvent (e.g. a bad entity cast, a misbehaving
+		// garden API client) must not bring down the whole event loop, so
+		// every event goes through the recovery middleware.
+		handleEvent := recovery.WithRecovery(c.processEvent)
 		for {
 			select {
 			case bundle := <-ch:
@@ -63,7 +108,7 @@ func (c *ContainerTagger) Start(ctx context.Context) {
 				close(bundle.Ch)
 
 				for _, evt := range bundle.Events {
-					err := c.processEvent(ctx, evt)
+					err := handleEvent(ctx, evt)
 					if err != nil {
 						log.Warnf("%v", err)
 					}
@@ -95,35 +140,91 @@ func (c *ContainerTagger) processEvent(ctx context.Context, evt workloadmeta.Eve
 		// will be useful for deletion
 		c.tagsHashByContainerID[containerID] = tagsHash
 
-		// check if the tags were already injected
-		if _, exist := c.seen[tagsHash]; exist {
-			return nil
-		}
+		var container garden.Container
+		if _, exist := c.seen[tagsHash]; !exist {
+			// mark as seen
+			c.seen[tagsHash] = struct{}{}
 
-		// mark as seen
-		c.seen[tagsHash] = struct{}{}
+			var err error
+			container, err = c.gardenUtil.GetContainer(containerID)
+			if err != nil {
+				return fmt.Errorf("error retrieving container %s from the garden API: %v", containerID, err)
+			}
 
-		container, err := c.gardenUtil.GetContainer(containerID)
-		if err != nil {
-			return fmt.Errorf("error retrieving container %s from the garden API: %v", containerID, err)
+			log.Infof("Updating tags in container %s", containerID)
+			go func() {
+				if err := updateTagsInContainer(container, tags); err != nil {
+					log.Errorf("Error running a process inside container %s: %v", containerID, err)
+				}
+			}()
 		}
 
-		log.Infof("Updating tags in container %s", containerID)
-		go func() {
-			err = updateTagsInContainer(container, tags)
+		if len(c.metadataProviders) == 0 {
+			return nil
+		}
+
+		if container == nil {
+			var err error
+			container, err = c.gardenUtil.GetContainer(containerID)
 			if err != nil {
-				log.Errorf("Error running a process inside container %s: %v", containerID, err)
+				return fmt.Errorf("error retrieving container %s from the garden API: %v", containerID, err)
 			}
-		}()
+		}
 
+		return c.injectMetadata(ctx, container, containerID, storeContainer)
 	} else if evt.Type == workloadmeta.EventTypeUnset {
 		hash := c.tagsHashByContainerID[containerID]
 		delete(c.seen, hash)
 		delete(c.tagsHashByContainerID, containerID)
+		delete(c.metadataHashesByContainerID, containerID)
 	}
 	return nil
 }
 
+// injectMetadata streams every registered MetadataProvider's document into
+// container, skipping providers whose output hasn't changed since the last
+// injection for this specific container.
+func (c *ContainerTagger) injectMetadata(ctx context.Context, container garden.Container, containerID string, storeContainer *workloadmeta.Container) error {
+	hashes, ok := c.metadataHashesByContainerID[containerID]
+	if !ok {
+		hashes = make(map[string]string)
+		c.metadataHashesByContainerID[containerID] = hashes
+	}
+
+	for _, provider := range c.metadataProviders {
+		metadata, err := provider.Collect(ctx, storeContainer)
+		if err != nil {
+			log.Warnf("error collecting %s metadata for container %s: %v", provider.Name(), containerID, err)
+			continue
+		}
+		if metadata == nil {
+			continue
+		}
+
+		content, err := json.Marshal(metadata)
+		if err != nil {
+			log.Warnf("error marshaling %s metadata for container %s: %v", provider.Name(), containerID, err)
+			continue
+		}
+
+		contentHash := hashBytes(content)
+		if hashes[provider.Name()] == contentHash {
+			continue
+		}
+		hashes[provider.Name()] = contentHash
+
+		name := provider.Name()
+		log.Infof("Updating %s metadata in container %s", name, containerID)
+		go func() {
+			if err := streamMetadataFile(container, name+".json", content); err != nil {
+				log.Errorf("Error streaming %s metadata into container %s: %v", name, containerID, err)
+			}
+		}()
+	}
+
+	return nil
+}
+
 // updateTagsInContainer runs a script inside the container that handles updating the agent with the given tags
 func updateTagsInContainer(container garden.Container, tags []string) error {
 	process, err := container.Run(garden.ProcessSpec{
@@ -142,3 +243,35 @@ func updateTagsInContainer(container garden.Container, tags []string) error {
 	log.Debugf("Process %s exited with code: %d", process.ID(), exitCode)
 	return nil
 }
+
+// streamMetadataFile tar-streams a single file named name, containing
+// content, into container at metadataBasePath via Garden's StreamIn.
+func streamMetadataFile(container garden.Container, name string, content []byte) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	if err := tw.WriteHeader(&tar.Header{
+		Name: name,
+		Mode: 0644,
+		Size: int64(len(content)),
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return container.StreamIn(garden.StreamInSpec{
+		Path:      metadataBasePath,
+		User:      "vcap",
+		TarStream: &buf,
+	})
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
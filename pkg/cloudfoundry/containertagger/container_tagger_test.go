@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package containertagger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta/recovery"
+)
+
+// TestProcessEventPanicIsRecovered asserts that a panic inside processEvent
+// (here, the event carries a nil Entity, so the GetID call at the top of
+// processEvent dereferences nil) is turned into an error by the recovery middleware instead
+// of crashing the event loop Start runs it in.
+func TestProcessEventPanicIsRecovered(t *testing.T) {
+	ct := &ContainerTagger{
+		seen:                        make(map[string]struct{}),
+		tagsHashByContainerID:       make(map[string]string),
+		metadataHashesByContainerID: make(map[string]map[string]string),
+	}
+
+	handleEvent := recovery.WithRecovery(ct.processEvent)
+
+	err := handleEvent(context.Background(), workloadmeta.Event{
+		Type:   workloadmeta.EventTypeSet,
+		Entity: nil,
+	})
+
+	var panicErr *recovery.PanicError
+	assert.ErrorAs(t, err, &panicErr)
+}
@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package dtls classifies UDP traffic as DTLS and extracts handshake
+// metadata (the ClientHello's version and SNI) akin to what the TLS
+// classifier already surfaces for TCP, so DTLS-carried protocols like WebRTC data channels,
+// SIP/media, and CoAP-secure don't have to be treated as opaque UDP.
+// IsDTLS/ParseClientHello are standalone parsing helpers today: no USM
+// protocol registry entry or monitoring config flag calls into this
+// package yet.
+package dtls
+
+import "encoding/binary"
+
+// Handshake message types relevant to classification (RFC 6347 §4.3.2,
+// shared with TLS's numbering).
+const (
+	handshakeTypeClientHello byte = 0x01
+	handshakeTypeServerHello byte = 0x02
+)
+
+const sniExtensionType uint16 = 0x0000
+const serverNameTypeHostName byte = 0x00
+
+// recordHeaderLen is the DTLS record header length: content type(1) +
+// version(2) + epoch(2) + sequence(6) + length(2).
+const recordHeaderLen = 13
+
+// handshakeHeaderLen is the DTLS handshake fragment header length:
+// msg_type(1) + length(3) + message_seq(2) + fragment_offset(3) +
+// fragment_length(3).
+const handshakeHeaderLen = 12
+
+// Info is the handshake metadata extracted from a ClientHello (its version
+// field and SNI), mirroring the TLSTags shape already exposed for TCP TLS
+// connections.
+type Info struct {
+	Version string
+	SNI     string
+}
+
+// recordVersions maps the wire-format version bytes DTLS carries (inverted
+// relative to TLS) to a version string.
+var recordVersions = map[[2]byte]string{
+	{0xfe, 0xff}: "DTLSv1.0",
+	{0xfe, 0xfd}: "DTLSv1.2",
+	{0xfe, 0xfc}: "DTLSv1.3",
+}
+
+// IsDTLS reports whether buf opens with a DTLS record header: a recognized
+// content type byte followed by a known DTLS version field. It would serve
+// as USM's entry-point classifier for UDP payloads, analogous to how the
+// TCP TLS classifier keys off the record header before attempting a full
+// parse, once something in the tracer calls it.
+func IsDTLS(buf []byte) bool {
+	if len(buf) < recordHeaderLen {
+		return false
+	}
+	contentType := buf[0]
+	if contentType < 20 || contentType > 26 {
+		return false
+	}
+	_, ok := recordVersions[[2]byte{buf[1], buf[2]}]
+	return ok
+}
+
+// ParseClientHello extracts the SNI extension from a DTLS ClientHello
+// carried in a single (unfragmented) handshake record payload. It returns
+// ok=false if buf isn't a ClientHello, carries an unknown version, or ends
+// before its cipher suites; a ClientHello with no SNI extension is still
+// ok=true, with an empty Info.SNI.
+//
+// Field layout after the 12-byte handshake header: legacy_version(2) +
+// random(32) + session_id (1-length-prefixed) + cookie (1-length-prefixed,
+// DTLS-specific relative to TLS) + cipher_suites (2-length-prefixed) +
+// compression_methods (1-length-prefixed) + extensions (2-length-prefixed).
+func ParseClientHello(buf []byte) (info Info, ok bool) {
+	if len(buf) < handshakeHeaderLen+2+32+1 || buf[0] != handshakeTypeClientHello {
+		return info, false
+	}
+	version, known := recordVersions[[2]byte{buf[handshakeHeaderLen], buf[handshakeHeaderLen+1]}]
+	if !known {
+		return info, false
+	}
+	info.Version = version
+
+	pos := handshakeHeaderLen + 2 + 32
+
+	sessionIDLen := int(buf[pos])
+	pos += 1 + sessionIDLen
+	if pos >= len(buf) {
+		return info, false
+	}
+
+	cookieLen := int(buf[pos])
+	pos += 1 + cookieLen
+	if pos+2 > len(buf) {
+		return info, false
+	}
+
+	cipherSuitesLen := int(binary.BigEndian.Uint16(buf[pos : pos+2]))
+	pos += 2 + cipherSuitesLen
+	if pos >= len(buf) {
+		return info, true
+	}
+
+	compressionLen := int(buf[pos])
+	pos += 1 + compressionLen
+	if pos+2 > len(buf) {
+		return info, true
+	}
+
+	extensionsLen := int(binary.BigEndian.Uint16(buf[pos : pos+2]))
+	pos += 2
+	if pos+extensionsLen > len(buf) {
+		return info, true
+	}
+
+	sni, found := parseSNIExtension(buf[pos : pos+extensionsLen])
+	if found {
+		info.SNI = sni
+	}
+	return info, true
+}
+
+// parseSNIExtension walks a ClientHello's extensions block looking for the
+// server_name extension and returns the first hostname entry within it.
+func parseSNIExtension(extensions []byte) (string, bool) {
+	pos := 0
+	for pos+4 <= len(extensions) {
+		extType := binary.BigEndian.Uint16(extensions[pos : pos+2])
+		extLen := int(binary.BigEndian.Uint16(extensions[pos+2 : pos+4]))
+		pos += 4
+		if pos+extLen > len(extensions) {
+			return "", false
+		}
+		if extType == sniExtensionType {
+			return parseServerNameList(extensions[pos : pos+extLen])
+		}
+		pos += extLen
+	}
+	return "", false
+}
+
+// parseServerNameList parses the server_name_list body of the SNI
+// extension (RFC 6066 §3) and returns the first host_name entry.
+func parseServerNameList(body []byte) (string, bool) {
+	if len(body) < 2 {
+		return "", false
+	}
+	listLen := int(binary.BigEndian.Uint16(body[0:2]))
+	pos := 2
+	end := 2 + listLen
+	if end > len(body) {
+		end = len(body)
+	}
+	for pos+3 <= end {
+		nameType := body[pos]
+		nameLen := int(binary.BigEndian.Uint16(body[pos+1 : pos+3]))
+		pos += 3
+		if pos+nameLen > len(body) {
+			return "", false
+		}
+		if nameType == serverNameTypeHostName {
+			return string(body[pos : pos+nameLen]), true
+		}
+		pos += nameLen
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dtls
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildRecordHeader(contentType byte, version [2]byte) []byte {
+	buf := make([]byte, recordHeaderLen)
+	buf[0] = contentType
+	buf[1], buf[2] = version[0], version[1]
+	return buf
+}
+
+func TestIsDTLS(t *testing.T) {
+	assert.True(t, IsDTLS(buildRecordHeader(22, [2]byte{0xfe, 0xfd})))
+	assert.False(t, IsDTLS(buildRecordHeader(22, [2]byte{0x03, 0x03}))) // TLS 1.2, not DTLS
+	assert.False(t, IsDTLS([]byte{0x16}))
+}
+
+func buildClientHello(sni string) []byte {
+	var extBody []byte
+	if sni != "" {
+		nameEntry := make([]byte, 3+len(sni))
+		nameEntry[0] = serverNameTypeHostName
+		binary.BigEndian.PutUint16(nameEntry[1:3], uint16(len(sni)))
+		copy(nameEntry[3:], sni)
+
+		list := make([]byte, 2+len(nameEntry))
+		binary.BigEndian.PutUint16(list[0:2], uint16(len(nameEntry)))
+		copy(list[2:], nameEntry)
+
+		ext := make([]byte, 4+len(list))
+		binary.BigEndian.PutUint16(ext[0:2], sniExtensionType)
+		binary.BigEndian.PutUint16(ext[2:4], uint16(len(list)))
+		copy(ext[4:], list)
+		extBody = ext
+	}
+
+	body := make([]byte, 0, 128)
+	body = append(body, 0xfe, 0xfd) // legacy_version
+	body = append(body, make([]byte, 32)...) // random
+	body = append(body, 0)                   // session_id len
+	body = append(body, 0)                   // cookie len
+	body = append(body, 0, 2, 0x13, 0x01)     // cipher_suites (len=2, one suite)
+	body = append(body, 1, 0)                // compression_methods (len=1, null)
+	extLenField := make([]byte, 2)
+	binary.BigEndian.PutUint16(extLenField, uint16(len(extBody)))
+	body = append(body, extLenField...)
+	body = append(body, extBody...)
+
+	msg := make([]byte, handshakeHeaderLen+len(body))
+	msg[0] = handshakeTypeClientHello
+	totalLen := uint32(len(body))
+	msg[1], msg[2], msg[3] = byte(totalLen>>16), byte(totalLen>>8), byte(totalLen)
+	copy(msg[handshakeHeaderLen:], body)
+	return msg
+}
+
+func TestParseClientHelloExtractsSNI(t *testing.T) {
+	msg := buildClientHello("media.example.com")
+
+	info, ok := ParseClientHello(msg)
+	require.True(t, ok)
+	assert.Equal(t, "DTLSv1.2", info.Version)
+	assert.Equal(t, "media.example.com", info.SNI)
+}
+
+func TestParseClientHelloNoSNI(t *testing.T) {
+	msg := buildClientHello("")
+
+	info, ok := ParseClientHello(msg)
+	require.True(t, ok)
+	assert.Equal(t, "DTLSv1.2", info.Version)
+	assert.Empty(t, info.SNI)
+}
+
+func TestParseClientHelloRejectsNonClientHello(t *testing.T) {
+	msg := buildClientHello("x")
+	msg[0] = handshakeTypeServerHello
+
+	_, ok := ParseClientHello(msg)
+	assert.False(t, ok)
+}
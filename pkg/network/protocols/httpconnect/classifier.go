@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package httpconnect detects HTTP CONNECT tunnels (RFC 7231 §4.3.6), for
+// eventually tagging the proxied inner endpoint and handing classification
+// off to whatever protocol (typically TLS) flows after the tunnel is
+// established, instead of permanently locking the connection as "HTTP".
+// Nothing in the tracer calls into this package yet; ParseConnectRequest/
+// IsSuccessResponse/Tags are standalone parsing helpers today.
+package httpconnect
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Tunnel is the inner endpoint a CONNECT request asked the proxy to tunnel
+// to, e.g. "CONNECT example.com:443 HTTP/1.1" -> Host: "example.com",
+// Port: 443.
+type Tunnel struct {
+	Host string
+	Port int
+}
+
+// ParseConnectRequest parses buf as an HTTP CONNECT request line, returning
+// the requested inner endpoint. It does not require the full request (only
+// the request line is needed to classify the tunnel), but buf may contain
+// trailing headers, which are ignored.
+func ParseConnectRequest(buf []byte) (Tunnel, bool) {
+	line, _, ok := bytes.Cut(buf, []byte("\r\n"))
+	if !ok {
+		line = buf
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) != 3 || fields[0] != "CONNECT" || !strings.HasPrefix(fields[2], "HTTP/") {
+		return Tunnel{}, false
+	}
+
+	host, portStr, ok := strings.Cut(fields[1], ":")
+	if !ok {
+		return Tunnel{}, false
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return Tunnel{}, false
+	}
+
+	return Tunnel{Host: host, Port: port}, true
+}
+
+// IsSuccessResponse reports whether buf is a "200" status-line response to
+// a CONNECT request (the proxy's ack that the tunnel is up and raw bytes
+// from here on belong to whatever the client tunnels through it).
+func IsSuccessResponse(buf []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	if !scanner.Scan() {
+		return false
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "HTTP/") {
+		return false
+	}
+	return fields[1] == "200"
+}
+
+// Tags returns the dynamic tags a consumer would attach to a connection
+// once its CONNECT tunnel has been established.
+func (t Tunnel) Tags() map[string]string {
+	return map[string]string{
+		"http.connect_tunnel_host": t.Host,
+		"http.connect_tunnel_port": strconv.Itoa(t.Port),
+	}
+}
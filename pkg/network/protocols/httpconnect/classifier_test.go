@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package httpconnect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConnectRequest(t *testing.T) {
+	req := []byte("CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n")
+
+	tunnel, ok := ParseConnectRequest(req)
+	require.True(t, ok)
+	assert.Equal(t, "example.com", tunnel.Host)
+	assert.Equal(t, 443, tunnel.Port)
+}
+
+func TestParseConnectRequestRejectsNonConnect(t *testing.T) {
+	req := []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	_, ok := ParseConnectRequest(req)
+	assert.False(t, ok)
+}
+
+func TestIsSuccessResponse(t *testing.T) {
+	assert.True(t, IsSuccessResponse([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")))
+	assert.False(t, IsSuccessResponse([]byte("HTTP/1.1 407 Proxy Authentication Required\r\n\r\n")))
+	assert.False(t, IsSuccessResponse([]byte("not http at all")))
+}
+
+func TestTunnelTags(t *testing.T) {
+	tunnel := Tunnel{Host: "example.com", Port: 443}
+	tags := tunnel.Tags()
+	assert.Equal(t, "example.com", tags["http.connect_tunnel_host"])
+	assert.Equal(t, "443", tags["http.connect_tunnel_port"])
+}
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package socks5 classifies SOCKS5 (RFC 1928) connections and extracts the
+// tunneled destination, for eventually tagging the outer connection to the
+// proxy with what it's actually carrying. Nothing in the tracer calls into
+// this package yet; IsClientGreeting/ParseRequest/Tags are standalone
+// parsing helpers today.
+package socks5
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+)
+
+const socks5Version byte = 0x05
+
+// Command identifies a SOCKS5 request's CMD field.
+type Command byte
+
+const (
+	CommandConnect      Command = 0x01
+	CommandBind         Command = 0x02
+	CommandUDPAssociate Command = 0x03
+)
+
+func (c Command) String() string {
+	switch c {
+	case CommandConnect:
+		return "CONNECT"
+	case CommandBind:
+		return "BIND"
+	case CommandUDPAssociate:
+		return "UDP_ASSOC"
+	default:
+		return fmt.Sprintf("UNKNOWN(%#x)", byte(c))
+	}
+}
+
+// addressType identifies a SOCKS5 request/reply's ATYP field.
+const (
+	addressTypeIPv4   byte = 0x01
+	addressTypeDomain byte = 0x03
+	addressTypeIPv6   byte = 0x04
+)
+
+// Destination is the tunneled endpoint extracted from a SOCKS5 request,
+// surfaced as dynamic tags on the outer (proxy) connection.
+type Destination struct {
+	Command Command
+	Host    string // dotted/colon IP literal, or domain name for ATYP=0x03
+	Port    uint16
+}
+
+// IsClientGreeting reports whether buf is a SOCKS5 client greeting:
+// VER(0x05) NMETHODS METHODS[NMETHODS].
+func IsClientGreeting(buf []byte) bool {
+	if len(buf) < 2 || buf[0] != socks5Version {
+		return false
+	}
+	nmethods := int(buf[1])
+	return len(buf) >= 2+nmethods
+}
+
+// ParseUsernamePasswordAuth parses the optional username/password
+// subnegotiation request (RFC 1929): VER(0x01) ULEN USERNAME PLEN PASSWORD.
+// It returns only whether the message is well-formed — credentials
+// themselves are not surfaced as tags.
+func ParseUsernamePasswordAuth(buf []byte) bool {
+	if len(buf) < 2 || buf[0] != 0x01 {
+		return false
+	}
+	ulen := int(buf[1])
+	pos := 2 + ulen
+	if pos >= len(buf) {
+		return false
+	}
+	plen := int(buf[pos])
+	pos++
+	return pos+plen <= len(buf)
+}
+
+// ParseRequest parses a SOCKS5 request: VER(0x05) CMD RSV(0x00) ATYP
+// DST.ADDR DST.PORT, returning the tunneled Destination.
+func ParseRequest(buf []byte) (Destination, bool) {
+	if len(buf) < 4 || buf[0] != socks5Version {
+		return Destination{}, false
+	}
+
+	dest := Destination{Command: Command(buf[1])}
+	pos := 4
+
+	switch buf[3] {
+	case addressTypeIPv4:
+		if len(buf) < pos+4+2 {
+			return Destination{}, false
+		}
+		addr := netip.AddrFrom4([4]byte{buf[pos], buf[pos+1], buf[pos+2], buf[pos+3]})
+		dest.Host = addr.String()
+		pos += 4
+	case addressTypeIPv6:
+		if len(buf) < pos+16+2 {
+			return Destination{}, false
+		}
+		var addrBytes [16]byte
+		copy(addrBytes[:], buf[pos:pos+16])
+		dest.Host = netip.AddrFrom16(addrBytes).String()
+		pos += 16
+	case addressTypeDomain:
+		if len(buf) < pos+1 {
+			return Destination{}, false
+		}
+		domainLen := int(buf[pos])
+		pos++
+		if len(buf) < pos+domainLen+2 {
+			return Destination{}, false
+		}
+		dest.Host = string(buf[pos : pos+domainLen])
+		pos += domainLen
+	default:
+		return Destination{}, false
+	}
+
+	dest.Port = binary.BigEndian.Uint16(buf[pos : pos+2])
+	return dest, true
+}
+
+// Tags returns the dynamic tag set a consumer would attach to the outer
+// proxy connection once a SOCKS5 request has been classified.
+func (d Destination) Tags() map[string]string {
+	return map[string]string{
+		"socks5.cmd":      d.Command.String(),
+		"socks5.dst_host": d.Host,
+		"socks5.dst_port": fmt.Sprintf("%d", d.Port),
+	}
+}
@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package socks5
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsClientGreeting(t *testing.T) {
+	assert.True(t, IsClientGreeting([]byte{0x05, 0x02, 0x00, 0x02}))
+	assert.False(t, IsClientGreeting([]byte{0x04, 0x02, 0x00, 0x02}))
+	assert.False(t, IsClientGreeting([]byte{0x05, 0x02, 0x00}))
+}
+
+func TestParseUsernamePasswordAuth(t *testing.T) {
+	msg := []byte{0x01, 4, 'u', 's', 'e', 'r', 4, 'p', 'a', 's', 's'}
+	assert.True(t, ParseUsernamePasswordAuth(msg))
+	assert.False(t, ParseUsernamePasswordAuth([]byte{0x01, 10}))
+}
+
+func TestParseRequestIPv4Connect(t *testing.T) {
+	buf := []byte{0x05, byte(CommandConnect), 0x00, 0x01, 93, 184, 216, 34, 0, 0}
+	binary.BigEndian.PutUint16(buf[8:], 80)
+
+	dest, ok := ParseRequest(buf)
+	require.True(t, ok)
+	assert.Equal(t, CommandConnect, dest.Command)
+	assert.Equal(t, "93.184.216.34", dest.Host)
+	assert.Equal(t, uint16(80), dest.Port)
+}
+
+func TestParseRequestDomainUDPAssociate(t *testing.T) {
+	domain := "example.com"
+	buf := []byte{0x05, byte(CommandUDPAssociate), 0x00, 0x03, byte(len(domain))}
+	buf = append(buf, domain...)
+	portBytes := make([]byte, 2)
+	binary.BigEndian.PutUint16(portBytes, 443)
+	buf = append(buf, portBytes...)
+
+	dest, ok := ParseRequest(buf)
+	require.True(t, ok)
+	assert.Equal(t, CommandUDPAssociate, dest.Command)
+	assert.Equal(t, domain, dest.Host)
+	assert.Equal(t, uint16(443), dest.Port)
+}
+
+func TestParseRequestIPv6Connect(t *testing.T) {
+	buf := make([]byte, 4+16+2)
+	buf[0], buf[1], buf[2], buf[3] = 0x05, byte(CommandConnect), 0x00, 0x04
+	addrBytes := []byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01}
+	copy(buf[4:20], addrBytes)
+	binary.BigEndian.PutUint16(buf[20:22], 8080)
+
+	dest, ok := ParseRequest(buf)
+	require.True(t, ok)
+	assert.Equal(t, "2001:db8::1", dest.Host)
+	assert.Equal(t, uint16(8080), dest.Port)
+}
+
+func TestDestinationTags(t *testing.T) {
+	dest := Destination{Command: CommandConnect, Host: "example.com", Port: 443}
+	tags := dest.Tags()
+	assert.Equal(t, "CONNECT", tags["socks5.cmd"])
+	assert.Equal(t, "example.com", tags["socks5.dst_host"])
+	assert.Equal(t, "443", tags["socks5.dst_port"])
+}
@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+import "encoding/binary"
+
+// DTLS record content types, mirroring the TLS ones used elsewhere in this
+// package (RFC 6347 §4.1).
+const (
+	dtlsContentTypeChangeCipherSpec byte = 20
+	dtlsContentTypeAlert            byte = 21
+	dtlsContentTypeHandshake        byte = 22
+	dtlsContentTypeApplicationData  byte = 23
+	dtlsContentTypeHeartbeat        byte = 24
+)
+
+// dtlsVersions maps the wire-format {major, minor} bytes DTLS inverts from
+// TLS's monotonic numbering (RFC 6347 §4.2.1) to a human name.
+var dtlsVersions = map[[2]byte]string{
+	{0xfe, 0xff}: "DTLS 1.0",
+	{0xfe, 0xfd}: "DTLS 1.2",
+	{0xfe, 0xfc}: "DTLS 1.3",
+}
+
+// dtlsRecordHeaderLen is the fixed length of a DTLS record header: content
+// type (1) + version (2) + epoch (2) + sequence number (6) + length (2).
+const dtlsRecordHeaderLen = 13
+
+// dtlsRecordHeader is a parsed DTLS record header.
+type dtlsRecordHeader struct {
+	ContentType byte
+	Version     string
+	Epoch       uint16
+	Sequence    uint64 // 48-bit sequence number
+	Length      uint16
+}
+
+// parseDTLSRecordHeader reads a single DTLS record header from the start of
+// buf. It returns ok=false if buf is too short or the version field doesn't
+// match a known DTLS version, which is how the classifier tells DTLS traffic
+// apart from arbitrary UDP payloads.
+func parseDTLSRecordHeader(buf []byte) (hdr dtlsRecordHeader, ok bool) {
+	if len(buf) < dtlsRecordHeaderLen {
+		return hdr, false
+	}
+
+	version, known := dtlsVersions[[2]byte{buf[1], buf[2]}]
+	if !known {
+		return hdr, false
+	}
+
+	seqBytes := make([]byte, 8)
+	copy(seqBytes[2:], buf[5:11])
+
+	hdr = dtlsRecordHeader{
+		ContentType: buf[0],
+		Version:     version,
+		Epoch:       binary.BigEndian.Uint16(buf[3:5]),
+		Sequence:    binary.BigEndian.Uint64(seqBytes),
+		Length:      binary.BigEndian.Uint16(buf[11:13]),
+	}
+	return hdr, true
+}
+
+// IsDTLS reports whether buf begins with a record header matching a known
+// DTLS content type and version, which is enough signal to classify a UDP
+// flow as DTLS without requiring a full handshake parse.
+func IsDTLS(buf []byte) bool {
+	hdr, ok := parseDTLSRecordHeader(buf)
+	if !ok {
+		return false
+	}
+	switch hdr.ContentType {
+	case dtlsContentTypeChangeCipherSpec, dtlsContentTypeAlert, dtlsContentTypeHandshake, dtlsContentTypeApplicationData, dtlsContentTypeHeartbeat:
+		return true
+	default:
+		return false
+	}
+}
+
+// dtlsHandshakeFragment is a single handshake-message fragment as carried in
+// a DTLS handshake record (RFC 6347 §4.2.2): unlike TLS, DTLS fragments
+// handshake messages independently of the UDP datagrams carrying them, so a
+// ClientHello can arrive split across several records with overlapping or
+// out-of-order fragment offsets.
+type dtlsHandshakeFragment struct {
+	MsgType        byte
+	MessageLength  uint32 // 24-bit total length of the whole message
+	MessageSeq     uint16
+	FragmentOffset uint32 // 24-bit
+	FragmentLength uint32 // 24-bit
+	Body           []byte
+}
+
+const dtlsHandshakeHeaderLen = 12
+
+// parseDTLSHandshakeFragment parses the 12-byte DTLS handshake fragment
+// header (msg_type, length, message_seq, fragment_offset, fragment_length)
+// followed by fragment_length bytes of body, from the start of a DTLS
+// handshake record's payload.
+func parseDTLSHandshakeFragment(buf []byte) (frag dtlsHandshakeFragment, ok bool) {
+	if len(buf) < dtlsHandshakeHeaderLen {
+		return frag, false
+	}
+
+	fragOffset := uint32(buf[6])<<16 | uint32(buf[7])<<8 | uint32(buf[8])
+	fragLength := uint32(buf[9])<<16 | uint32(buf[10])<<8 | uint32(buf[11])
+
+	if uint32(len(buf)-dtlsHandshakeHeaderLen) < fragLength {
+		return frag, false
+	}
+
+	frag = dtlsHandshakeFragment{
+		MsgType:        buf[0],
+		MessageLength:  uint32(buf[1])<<16 | uint32(buf[2])<<8 | uint32(buf[3]),
+		MessageSeq:     binary.BigEndian.Uint16(buf[4:6]),
+		FragmentOffset: fragOffset,
+		FragmentLength: fragLength,
+		Body:           buf[dtlsHandshakeHeaderLen : dtlsHandshakeHeaderLen+fragLength],
+	}
+	return frag, true
+}
+
+// dtlsHandshakeReassembler reassembles a single handshake message (e.g. a
+// ClientHello split across several DTLS records) from its fragments,
+// deduplicating overlapping fragments so retransmissions don't corrupt the
+// result.
+type dtlsHandshakeReassembler struct {
+	msgType    byte
+	messageSeq uint16
+	started    bool
+	have       map[uint32]byte // byte offset -> filled marker
+	buf        []byte
+	complete   bool
+}
+
+func newDTLSHandshakeReassembler() *dtlsHandshakeReassembler {
+	return &dtlsHandshakeReassembler{have: make(map[uint32]byte)}
+}
+
+// Add feeds a single fragment into the reassembler. It returns the fully
+// reassembled message body once every byte offset in [0, total) has been
+// covered by at least one fragment.
+//
+// A fragment whose MsgType/MessageSeq don't match the message currently
+// being assembled belongs to a different handshake message (e.g. the peer
+// moved on before we finished reassembling, or retransmitted with a bumped
+// message_seq); rather than silently merging unrelated fragments into one
+// corrupted result, Add resets and starts reassembling that new message.
+func (r *dtlsHandshakeReassembler) Add(frag dtlsHandshakeFragment) (body []byte, complete bool) {
+	if r.started && (frag.MsgType != r.msgType || frag.MessageSeq != r.messageSeq) {
+		r.have = make(map[uint32]byte)
+		r.buf = nil
+		r.complete = false
+	}
+
+	if r.buf == nil {
+		// Size the buffer from the message's total length (the handshake
+		// header's "length" field), so a message isn't reported complete
+		// until every byte of it has arrived.
+		r.msgType = frag.MsgType
+		r.messageSeq = frag.MessageSeq
+		r.started = true
+		r.buf = make([]byte, frag.MessageLength)
+	}
+	needed := frag.FragmentOffset + frag.FragmentLength
+	if needed > uint32(len(r.buf)) {
+		grown := make([]byte, needed)
+		copy(grown, r.buf)
+		r.buf = grown
+	}
+
+	copy(r.buf[frag.FragmentOffset:], frag.Body)
+	for i := uint32(0); i < frag.FragmentLength; i++ {
+		r.have[frag.FragmentOffset+i] = 1
+	}
+
+	if uint32(len(r.have)) >= uint32(len(r.buf)) {
+		r.complete = true
+	}
+	return r.buf, r.complete
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildDTLSRecord(contentType byte, version [2]byte, epoch uint16, seq uint64, payload []byte) []byte {
+	buf := make([]byte, dtlsRecordHeaderLen+len(payload))
+	buf[0] = contentType
+	buf[1], buf[2] = version[0], version[1]
+	binary.BigEndian.PutUint16(buf[3:5], epoch)
+	seqBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(seqBytes, seq)
+	copy(buf[5:11], seqBytes[2:])
+	binary.BigEndian.PutUint16(buf[11:13], uint16(len(payload)))
+	copy(buf[dtlsRecordHeaderLen:], payload)
+	return buf
+}
+
+func TestIsDTLS(t *testing.T) {
+	record := buildDTLSRecord(dtlsContentTypeHandshake, [2]byte{0xfe, 0xfd}, 0, 1, []byte("clienthello-stub"))
+	assert.True(t, IsDTLS(record))
+
+	assert.False(t, IsDTLS([]byte("not dtls at all")))
+	assert.False(t, IsDTLS(nil))
+}
+
+func TestParseDTLSRecordHeader(t *testing.T) {
+	record := buildDTLSRecord(dtlsContentTypeHandshake, [2]byte{0xfe, 0xff}, 2, 0x0001020304, []byte("x"))
+
+	hdr, ok := parseDTLSRecordHeader(record)
+	require.True(t, ok)
+	assert.Equal(t, dtlsContentTypeHandshake, hdr.ContentType)
+	assert.Equal(t, "DTLS 1.0", hdr.Version)
+	assert.Equal(t, uint16(2), hdr.Epoch)
+	assert.Equal(t, uint64(0x0001020304), hdr.Sequence)
+	assert.Equal(t, uint16(1), hdr.Length)
+}
+
+func buildHandshakeFragment(msgType byte, messageSeq uint16, offset, total uint32, body []byte) []byte {
+	buf := make([]byte, dtlsHandshakeHeaderLen+len(body))
+	buf[0] = msgType
+	buf[1], buf[2], buf[3] = byte(total>>16), byte(total>>8), byte(total)
+	binary.BigEndian.PutUint16(buf[4:6], messageSeq)
+	buf[6], buf[7], buf[8] = byte(offset>>16), byte(offset>>8), byte(offset)
+	fragLen := uint32(len(body))
+	buf[9], buf[10], buf[11] = byte(fragLen>>16), byte(fragLen>>8), byte(fragLen)
+	copy(buf[dtlsHandshakeHeaderLen:], body)
+	return buf
+}
+
+func TestDTLSHandshakeReassemblyOutOfOrderFragments(t *testing.T) {
+	full := []byte("the quick brown fox jumps over the lazy dog")
+	part1 := full[:20]
+	part2 := full[20:]
+
+	frag2Bytes := buildHandshakeFragment(0x01, 0, 20, uint32(len(full)), part2)
+	frag1Bytes := buildHandshakeFragment(0x01, 0, 0, uint32(len(full)), part1)
+
+	frag2, ok := parseDTLSHandshakeFragment(frag2Bytes)
+	require.True(t, ok)
+	frag1, ok := parseDTLSHandshakeFragment(frag1Bytes)
+	require.True(t, ok)
+
+	reasm := newDTLSHandshakeReassembler()
+	_, complete := reasm.Add(frag2)
+	assert.False(t, complete)
+
+	body, complete := reasm.Add(frag1)
+	require.True(t, complete)
+	assert.Equal(t, full, body)
+}
+
+func TestDTLSHandshakeReassemblyResetsOnMessageMismatch(t *testing.T) {
+	firstPart := []byte("part of an abandoned message")
+	frag1Bytes := buildHandshakeFragment(0x01, 0, 0, uint32(len(firstPart))+10, firstPart)
+	frag1, ok := parseDTLSHandshakeFragment(frag1Bytes)
+	require.True(t, ok)
+
+	reasm := newDTLSHandshakeReassembler()
+	_, complete := reasm.Add(frag1)
+	assert.False(t, complete)
+
+	// A fragment with a different message_seq belongs to an unrelated
+	// handshake message; it must not be merged with the in-progress one.
+	second := []byte("a whole new message")
+	frag2Bytes := buildHandshakeFragment(0x01, 1, 0, uint32(len(second)), second)
+	frag2, ok := parseDTLSHandshakeFragment(frag2Bytes)
+	require.True(t, ok)
+
+	body, complete := reasm.Add(frag2)
+	require.True(t, complete)
+	assert.Equal(t, second, body)
+
+	// Same check for a differing MsgType with the same message_seq.
+	third := []byte("yet another message")
+	frag3Bytes := buildHandshakeFragment(0x02, 1, 0, uint32(len(third)), third)
+	frag3, ok := parseDTLSHandshakeFragment(frag3Bytes)
+	require.True(t, ok)
+
+	body, complete = reasm.Add(frag3)
+	require.True(t, complete)
+	assert.Equal(t, third, body)
+}
+
+func TestDTLSHandshakeReassemblyDeduplicatesRetransmits(t *testing.T) {
+	full := []byte("retransmitted fragment body")
+	fragBytes := buildHandshakeFragment(0x01, 0, 0, uint32(len(full)), full)
+	frag, ok := parseDTLSHandshakeFragment(fragBytes)
+	require.True(t, ok)
+
+	reasm := newDTLSHandshakeReassembler()
+	_, complete := reasm.Add(frag)
+	require.True(t, complete)
+
+	// A duplicate (retransmitted) fragment must not corrupt the already
+	// complete message or flip complete back to false.
+	body, complete := reasm.Add(frag)
+	assert.True(t, complete)
+	assert.Equal(t, full, body)
+}
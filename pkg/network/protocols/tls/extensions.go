@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+import "encoding/binary"
+
+// TLS extension types relevant to the tags this file extracts (IANA TLS
+// ExtensionType registry).
+const (
+	extensionServerName          uint16 = 0x0000
+	extensionALPN                uint16 = 0x0010
+	extensionSupportedGroups     uint16 = 0x000a
+	extensionSignatureAlgorithms uint16 = 0x000d
+)
+
+const serverNameTypeHostName byte = 0x00
+
+// namedGroups maps the IANA NamedGroup registry's numeric IDs to names (a
+// curated subset covering what crypto/tls's CurvePreferences can produce
+// plus the common non-Go ones seen in the wild).
+var namedGroups = map[uint16]string{
+	0x0017: "secp256r1",
+	0x0018: "secp384r1",
+	0x0019: "secp521r1",
+	0x001d: "x25519",
+	0x001e: "x448",
+}
+
+// signatureSchemes maps the IANA SignatureScheme registry's numeric IDs to
+// human-readable names.
+var signatureSchemes = map[uint16]string{
+	0x0401: "rsa_pkcs1_sha256",
+	0x0501: "rsa_pkcs1_sha384",
+	0x0601: "rsa_pkcs1_sha512",
+	0x0403: "ecdsa_secp256r1_sha256",
+	0x0503: "ecdsa_secp384r1_sha384",
+	0x0804: "rsa_pss_rsae_sha256",
+	0x0807: "ed25519",
+}
+
+// ClientHelloExtensions is the subset of a ClientHello's extensions this
+// package can extract as candidate tags beyond cipher suite ID and
+// negotiated version: SNI, the client's offered ALPN protocols, its
+// supported elliptic curve/key-exchange groups, and its supported
+// signature algorithms. Nothing currently feeds a real ClientHello's
+// extensions block into ParseClientHelloExtensions or attaches its output
+// as tags on a connection; this is a standalone parser today.
+type ClientHelloExtensions struct {
+	SNI                 string
+	ALPNOffered         []string
+	SupportedGroups     []string
+	SignatureAlgorithms []string
+}
+
+// ParseClientHelloExtensions walks a TLS ClientHello's extensions block
+// (the same wire format this package's DTLS sibling parses for SNI alone)
+// and extracts every extension this type knows how to surface as tags.
+// Unrecognized extensions are skipped, not treated as a parse error.
+func ParseClientHelloExtensions(extensions []byte) ClientHelloExtensions {
+	var out ClientHelloExtensions
+
+	pos := 0
+	for pos+4 <= len(extensions) {
+		extType := binary.BigEndian.Uint16(extensions[pos : pos+2])
+		extLen := int(binary.BigEndian.Uint16(extensions[pos+2 : pos+4]))
+		pos += 4
+		if pos+extLen > len(extensions) {
+			break
+		}
+		body := extensions[pos : pos+extLen]
+		pos += extLen
+
+		switch extType {
+		case extensionServerName:
+			if sni, ok := parseServerName(body); ok {
+				out.SNI = sni
+			}
+		case extensionALPN:
+			out.ALPNOffered = parseALPNProtocols(body)
+		case extensionSupportedGroups:
+			out.SupportedGroups = parseNamedList(body, namedGroups)
+		case extensionSignatureAlgorithms:
+			out.SignatureAlgorithms = parseNamedList(body, signatureSchemes)
+		}
+	}
+
+	return out
+}
+
+// parseServerName parses the server_name extension body (RFC 6066 §3).
+func parseServerName(body []byte) (string, bool) {
+	if len(body) < 2 {
+		return "", false
+	}
+	listLen := int(binary.BigEndian.Uint16(body[0:2]))
+	pos := 2
+	end := pos + listLen
+	if end > len(body) {
+		end = len(body)
+	}
+	for pos+3 <= end {
+		nameType := body[pos]
+		nameLen := int(binary.BigEndian.Uint16(body[pos+1 : pos+3]))
+		pos += 3
+		if pos+nameLen > len(body) {
+			return "", false
+		}
+		if nameType == serverNameTypeHostName {
+			return string(body[pos : pos+nameLen]), true
+		}
+		pos += nameLen
+	}
+	return "", false
+}
+
+// parseALPNProtocols parses the application_layer_protocol_negotiation
+// extension body (RFC 7301 §3.1): a 2-byte list length followed by
+// 1-length-prefixed protocol name strings.
+func parseALPNProtocols(body []byte) []string {
+	if len(body) < 2 {
+		return nil
+	}
+	listLen := int(binary.BigEndian.Uint16(body[0:2]))
+	pos := 2
+	end := pos + listLen
+	if end > len(body) {
+		end = len(body)
+	}
+
+	var protocols []string
+	for pos < end {
+		nameLen := int(body[pos])
+		pos++
+		if pos+nameLen > len(body) {
+			break
+		}
+		protocols = append(protocols, string(body[pos:pos+nameLen]))
+		pos += nameLen
+	}
+	return protocols
+}
+
+// parseNamedList parses a 2-byte-length-prefixed list of 2-byte IDs (the
+// wire shape shared by supported_groups and signature_algorithms) and
+// resolves each ID against names, skipping unrecognized IDs.
+func parseNamedList(body []byte, names map[uint16]string) []string {
+	if len(body) < 2 {
+		return nil
+	}
+	listLen := int(binary.BigEndian.Uint16(body[0:2]))
+	pos := 2
+	end := pos + listLen
+	if end > len(body) {
+		end = len(body)
+	}
+
+	var out []string
+	for pos+2 <= end {
+		id := binary.BigEndian.Uint16(body[pos : pos+2])
+		pos += 2
+		if name, ok := names[id]; ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func buildExtension(extType uint16, body []byte) []byte {
+	ext := make([]byte, 4+len(body))
+	binary.BigEndian.PutUint16(ext[0:2], extType)
+	binary.BigEndian.PutUint16(ext[2:4], uint16(len(body)))
+	copy(ext[4:], body)
+	return ext
+}
+
+func buildServerNameExtension(host string) []byte {
+	nameEntry := make([]byte, 3+len(host))
+	nameEntry[0] = serverNameTypeHostName
+	binary.BigEndian.PutUint16(nameEntry[1:3], uint16(len(host)))
+	copy(nameEntry[3:], host)
+
+	list := make([]byte, 2+len(nameEntry))
+	binary.BigEndian.PutUint16(list[0:2], uint16(len(nameEntry)))
+	copy(list[2:], nameEntry)
+
+	return buildExtension(extensionServerName, list)
+}
+
+func buildALPNExtension(protocols []string) []byte {
+	var protoBytes []byte
+	for _, p := range protocols {
+		protoBytes = append(protoBytes, byte(len(p)))
+		protoBytes = append(protoBytes, p...)
+	}
+	list := make([]byte, 2+len(protoBytes))
+	binary.BigEndian.PutUint16(list[0:2], uint16(len(protoBytes)))
+	copy(list[2:], protoBytes)
+	return buildExtension(extensionALPN, list)
+}
+
+func buildNamedListExtension(extType uint16, ids []uint16) []byte {
+	body := make([]byte, 2+2*len(ids))
+	binary.BigEndian.PutUint16(body[0:2], uint16(2*len(ids)))
+	for i, id := range ids {
+		binary.BigEndian.PutUint16(body[2+2*i:4+2*i], id)
+	}
+	return buildExtension(extType, body)
+}
+
+func TestParseClientHelloExtensions(t *testing.T) {
+	var extensions []byte
+	extensions = append(extensions, buildServerNameExtension("example.com")...)
+	extensions = append(extensions, buildALPNExtension([]string{"h2", "http/1.1"})...)
+	extensions = append(extensions, buildNamedListExtension(extensionSupportedGroups, []uint16{0x001d, 0x0017})...)
+	extensions = append(extensions, buildNamedListExtension(extensionSignatureAlgorithms, []uint16{0x0403, 0x0804})...)
+
+	info := ParseClientHelloExtensions(extensions)
+
+	assert.Equal(t, "example.com", info.SNI)
+	assert.Equal(t, []string{"h2", "http/1.1"}, info.ALPNOffered)
+	assert.Equal(t, []string{"x25519", "secp256r1"}, info.SupportedGroups)
+	assert.Equal(t, []string{"ecdsa_secp256r1_sha256", "rsa_pss_rsae_sha256"}, info.SignatureAlgorithms)
+}
+
+func TestParseClientHelloExtensionsSkipsUnknownIDs(t *testing.T) {
+	extensions := buildNamedListExtension(extensionSupportedGroups, []uint16{0xffff, 0x001d})
+	info := ParseClientHelloExtensions(extensions)
+	assert.Equal(t, []string{"x25519"}, info.SupportedGroups)
+}
+
+func TestParseClientHelloExtensionsEmpty(t *testing.T) {
+	info := ParseClientHelloExtensions(nil)
+	assert.Empty(t, info.SNI)
+	assert.Empty(t, info.ALPNOffered)
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+// Version identifiers as carried in the wire-format ClientHello/ServerHello
+// legacy_version and supported_versions fields.
+type Version uint16
+
+const (
+	VersionTLS10 Version = 0x0301
+	VersionTLS11 Version = 0x0302
+	VersionTLS12 Version = 0x0303
+	VersionTLS13 Version = 0x0304
+)
+
+// DefaultMinVersionFloor is the default floor TLSPolicyEnabled checks
+// negotiated versions against: connections below this are flagged weak.
+const DefaultMinVersionFloor = VersionTLS12
+
+// HandshakeFailureKind distinguishes how a TLS handshake failed, mirroring
+// the existing TCPFailures taxonomy's approach of bucketing failures by
+// cause rather than lumping them all together.
+type HandshakeFailureKind int
+
+const (
+	HandshakeFailureNone HandshakeFailureKind = iota
+	// HandshakeFailureAlertReceived means the peer sent a fatal alert.
+	HandshakeFailureAlertReceived
+	// HandshakeFailureAlertSent means we (or the local stack) sent a fatal
+	// alert, e.g. rejecting a ClientHello with no mutually supported
+	// version or cipher.
+	HandshakeFailureAlertSent
+	// HandshakeFailureTruncated means the connection closed mid-handshake
+	// with no alert at all (a TCP RST/FIN before Finished).
+	HandshakeFailureTruncated
+	// HandshakeFailureTimeout means no ServerHello/Finished arrived within
+	// the handshake timeout window.
+	HandshakeFailureTimeout
+)
+
+// TLS alert codes relevant to handshake failure classification (RFC 8446
+// §6).
+const (
+	AlertCloseNotify          uint8 = 0
+	AlertUnexpectedMessage    uint8 = 10
+	AlertHandshakeFailure     uint8 = 40
+	AlertProtocolVersion      uint8 = 70
+	AlertInsufficientSecurity uint8 = 71
+)
+
+// PolicyConfig would gate the weak-TLS/failed-handshake accounting behind a
+// TLSPolicyEnabled knob and carry the configured minimum version floor and
+// cipher suite allowlist; no such knob exists in the tracer's config today,
+// and nothing constructs a PolicyConfig outside this package's own tests.
+type PolicyConfig struct {
+	Enabled         bool
+	MinVersionFloor Version
+	AllowedCiphers  map[uint16]struct{} // empty means "allow all"
+}
+
+// PolicyCounters accumulates per-connection counters: how many handshakes
+// negotiated below the floor, used a non-allowlisted cipher, and failed,
+// the failures counted per alert code (reusing the TCPFailures map shape,
+// keyed by alert code instead of errno; see RecordFailure for the kinds that
+// carry no alert code). A received and a sent alert with the same code share
+// one entry. Nothing currently calls
+// RecordHandshake/RecordFailure from a live handshake-parsing path; this is
+// a standalone counters type today.
+type PolicyCounters struct {
+	WeakVersion uint64
+	WeakCipher  uint64
+	TLSFailures map[uint8]uint64
+}
+
+// NewPolicyCounters returns a zeroed PolicyCounters ready to accumulate.
+func NewPolicyCounters() *PolicyCounters {
+	return &PolicyCounters{TLSFailures: make(map[uint8]uint64)}
+}
+
+// RecordHandshake folds a completed (non-failed) handshake's negotiated
+// version and cipher suite into the counters per cfg's policy.
+func (c *PolicyCounters) RecordHandshake(cfg PolicyConfig, version Version, cipherSuite uint16) {
+	if !cfg.Enabled {
+		return
+	}
+	if version < cfg.MinVersionFloor {
+		c.WeakVersion++
+	}
+	if len(cfg.AllowedCiphers) > 0 {
+		if _, ok := cfg.AllowedCiphers[cipherSuite]; !ok {
+			c.WeakCipher++
+		}
+	}
+}
+
+// RecordFailure folds a failed handshake into TLSFailures, keyed by the
+// peer or local alert code for HandshakeFailureAlertReceived/AlertSent, or
+// by a synthetic bucket (250 for truncated, 251 for timeout) for the two
+// failure kinds that carry no alert code, mirroring how ETIMEDOUT doesn't
+// carry a wire error either.
+func (c *PolicyCounters) RecordFailure(cfg PolicyConfig, kind HandshakeFailureKind, alertCode uint8) {
+	if !cfg.Enabled || kind == HandshakeFailureNone {
+		return
+	}
+	switch kind {
+	case HandshakeFailureTruncated:
+		c.TLSFailures[250]++
+	case HandshakeFailureTimeout:
+		c.TLSFailures[251]++
+	default:
+		c.TLSFailures[alertCode]++
+	}
+}
+
+// IsWeak reports whether version violates floor. Nothing currently calls
+// IsWeak to set a tls.weak tag on ProtocolStack; that wiring doesn't exist
+// yet.
+func IsWeak(version Version, floor Version) bool {
+	return version < floor
+}
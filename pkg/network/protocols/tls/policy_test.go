@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCountersRecordHandshakeWeakVersion(t *testing.T) {
+	cfg := PolicyConfig{Enabled: true, MinVersionFloor: DefaultMinVersionFloor}
+	counters := NewPolicyCounters()
+
+	counters.RecordHandshake(cfg, VersionTLS11, 0x1301)
+	counters.RecordHandshake(cfg, VersionTLS13, 0x1301)
+
+	assert.EqualValues(t, 1, counters.WeakVersion)
+}
+
+func TestPolicyCountersRecordHandshakeWeakCipher(t *testing.T) {
+	cfg := PolicyConfig{
+		Enabled:         true,
+		MinVersionFloor: DefaultMinVersionFloor,
+		AllowedCiphers:  map[uint16]struct{}{0x1301: {}},
+	}
+	counters := NewPolicyCounters()
+
+	counters.RecordHandshake(cfg, VersionTLS13, 0x1301)
+	counters.RecordHandshake(cfg, VersionTLS13, 0xc013) // not in allowlist
+
+	assert.EqualValues(t, 1, counters.WeakCipher)
+}
+
+func TestPolicyCountersDisabledIsNoOp(t *testing.T) {
+	cfg := PolicyConfig{Enabled: false, MinVersionFloor: DefaultMinVersionFloor}
+	counters := NewPolicyCounters()
+
+	counters.RecordHandshake(cfg, VersionTLS10, 0x1301)
+	assert.Zero(t, counters.WeakVersion)
+}
+
+func TestPolicyCountersRecordFailure(t *testing.T) {
+	cfg := PolicyConfig{Enabled: true}
+	counters := NewPolicyCounters()
+
+	counters.RecordFailure(cfg, HandshakeFailureAlertReceived, AlertProtocolVersion)
+	counters.RecordFailure(cfg, HandshakeFailureTruncated, 0)
+	counters.RecordFailure(cfg, HandshakeFailureTimeout, 0)
+
+	assert.EqualValues(t, 1, counters.TLSFailures[AlertProtocolVersion])
+	assert.EqualValues(t, 1, counters.TLSFailures[250])
+	assert.EqualValues(t, 1, counters.TLSFailures[251])
+}
+
+func TestIsWeak(t *testing.T) {
+	assert.True(t, IsWeak(VersionTLS10, DefaultMinVersionFloor))
+	assert.False(t, IsWeak(VersionTLS12, DefaultMinVersionFloor))
+	assert.False(t, IsWeak(VersionTLS13, DefaultMinVersionFloor))
+}
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"net"
+	"time"
+)
+
+// Subnet identifies the network a connection's egress interface belongs to,
+// plus whatever cloud-provider placement metadata that provider can attach
+// (region/zone/VPC). Nothing currently attaches a resolved Subnet to a
+// connection; gwLookup's subnet resolution doesn't call into this package's
+// registry, so these fields aren't surfaced anywhere yet.
+type Subnet struct {
+	Alias  string
+	Region string
+	Zone   string
+	VPCID  string
+}
+
+// CloudProvider would be implemented once per supported cloud (or on-prem
+// mapping source) for gwLookup's subnet resolution, once something wires a
+// cloudProviderRegistry into that lookup. The registry calls Detect on every
+// lookup that misses its cache, to decide whether a provider is relevant to
+// the current environment (e.g. AWS's IMDS only responds when running on EC2).
+type CloudProvider interface {
+	// Name identifies the provider for logging and telemetry, e.g. "aws",
+	// "gcp", "azure", "static".
+	Name() string
+
+	// Detect reports whether this provider's backing environment (a cloud
+	// metadata service, a supplied static mapping file, ...) is present.
+	// Providers that return false are skipped by the registry.
+	Detect() bool
+
+	// SubnetForHwAddr resolves the subnet a given interface hardware address
+	// belongs to.
+	SubnetForHwAddr(hwAddr net.HardwareAddr) (Subnet, error)
+}
+
+// cloudProviderCacheEntry holds a cached SubnetForHwAddr result, including
+// negative results (subnet not found / provider error) so that repeated
+// lookups for the same interface within the TTL don't re-hit the provider.
+type cloudProviderCacheEntry struct {
+	subnet    Subnet
+	err       error
+	expiresAt time.Time
+}
+
+// cloudProviderRegistry tries each registered CloudProvider in priority
+// order (lowest index first) and caches the winning result per hardware
+// address, intended to mirror the single-call-per-address behavior of the
+// AWS gateway lookup. It is not wired into gwLookup or any other tracer
+// code path yet; it's a standalone registry today.
+type cloudProviderRegistry struct {
+	providers []CloudProvider
+	ttl       time.Duration
+	negTTL    time.Duration
+	cache     map[string]cloudProviderCacheEntry
+	nowFn     func() time.Time
+}
+
+// newCloudProviderRegistry builds a registry that tries providers in the
+// order given. ttl governs how long a successful lookup is cached; negTTL
+// governs how long a failed lookup (no provider could resolve the address)
+// is cached before being retried.
+func newCloudProviderRegistry(providers []CloudProvider, ttl, negTTL time.Duration) *cloudProviderRegistry {
+	return &cloudProviderRegistry{
+		providers: providers,
+		ttl:       ttl,
+		negTTL:    negTTL,
+		cache:     make(map[string]cloudProviderCacheEntry),
+		nowFn:     time.Now,
+	}
+}
+
+// SubnetForHwAddr returns the subnet for hwAddr, trying cached results first
+// and otherwise consulting providers in priority order until one succeeds.
+func (r *cloudProviderRegistry) SubnetForHwAddr(hwAddr net.HardwareAddr) (Subnet, error) {
+	key := hwAddr.String()
+	now := r.nowFn()
+
+	if entry, ok := r.cache[key]; ok && now.Before(entry.expiresAt) {
+		return entry.subnet, entry.err
+	}
+
+	var lastErr error
+	for _, p := range r.providers {
+		if !p.Detect() {
+			continue
+		}
+		subnet, err := p.SubnetForHwAddr(hwAddr)
+		if err == nil {
+			r.cache[key] = cloudProviderCacheEntry{subnet: subnet, expiresAt: now.Add(r.ttl)}
+			return subnet, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr == nil {
+		lastErr = errNoCloudProviderDetected
+	}
+	r.cache[key] = cloudProviderCacheEntry{err: lastErr, expiresAt: now.Add(r.negTTL)}
+	return Subnet{}, lastErr
+}
+
+var errNoCloudProviderDetected = subnetLookupError("no cloud provider detected for this environment")
+
+type subnetLookupError string
+
+func (e subnetLookupError) Error() string { return string(e) }
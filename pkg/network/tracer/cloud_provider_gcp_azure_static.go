@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import "net"
+
+// metadataFetcher abstracts an HTTP GET against a cloud metadata endpoint so
+// the GCP/Azure providers below are testable without a real metadata
+// service listening on 169.254.169.254 or metadata.google.internal.
+type metadataFetcher func(path string) (string, error)
+
+// gcpCloudProvider resolves subnets via the GCE metadata server
+// (metadata.google.internal).
+type gcpCloudProvider struct {
+	fetch metadataFetcher
+}
+
+func (p *gcpCloudProvider) Name() string { return "gcp" }
+
+func (p *gcpCloudProvider) Detect() bool {
+	_, err := p.fetch("/computeMetadata/v1/instance/zone")
+	return err == nil
+}
+
+func (p *gcpCloudProvider) SubnetForHwAddr(hwAddr net.HardwareAddr) (Subnet, error) {
+	subnetPath := "/computeMetadata/v1/instance/network-interfaces/by-mac/" + hwAddr.String() + "/subnetmask"
+	zonePath := "/computeMetadata/v1/instance/zone"
+	vpcPath := "/computeMetadata/v1/instance/network-interfaces/by-mac/" + hwAddr.String() + "/network"
+
+	alias, err := p.fetch(subnetPath)
+	if err != nil {
+		return Subnet{}, err
+	}
+	zone, _ := p.fetch(zonePath)
+	vpc, _ := p.fetch(vpcPath)
+
+	return Subnet{Alias: alias, Zone: zone, VPCID: vpc}, nil
+}
+
+// azureCloudProvider resolves subnets via Azure IMDS
+// (169.254.169.254/metadata/instance).
+type azureCloudProvider struct {
+	fetch metadataFetcher
+}
+
+func (p *azureCloudProvider) Name() string { return "azure" }
+
+func (p *azureCloudProvider) Detect() bool {
+	_, err := p.fetch("/metadata/instance/compute/vmId")
+	return err == nil
+}
+
+func (p *azureCloudProvider) SubnetForHwAddr(hwAddr net.HardwareAddr) (Subnet, error) {
+	macKey := normalizeAzureMAC(hwAddr)
+	subnetPath := "/metadata/instance/network/interface/mac/" + macKey + "/subnet"
+	regionPath := "/metadata/instance/compute/location"
+	vnetPath := "/metadata/instance/network/interface/mac/" + macKey + "/vnetId"
+
+	alias, err := p.fetch(subnetPath)
+	if err != nil {
+		return Subnet{}, err
+	}
+	region, _ := p.fetch(regionPath)
+	vnet, _ := p.fetch(vnetPath)
+
+	return Subnet{Alias: alias, Region: region, VPCID: vnet}, nil
+}
+
+// normalizeAzureMAC strips the colons IMDS's by-mac interface lookup
+// expects removed (e.g. "00:11:22:33:44:55" -> "001122334455").
+func normalizeAzureMAC(hwAddr net.HardwareAddr) string {
+	out := make([]byte, 0, len(hwAddr)*2)
+	const hex = "0123456789abcdef"
+	for _, b := range hwAddr {
+		out = append(out, hex[b>>4], hex[b&0xf])
+	}
+	return string(out)
+}
+
+// staticCloudProvider resolves subnets from a user-supplied MAC -> subnet
+// alias mapping, for on-prem environments with no cloud metadata service.
+type staticCloudProvider struct {
+	mapping map[string]Subnet
+}
+
+// newStaticCloudProvider builds a provider from a MAC address (as returned
+// by net.HardwareAddr.String()) to Subnet mapping, typically loaded from a
+// JSON/YAML config file.
+func newStaticCloudProvider(mapping map[string]Subnet) *staticCloudProvider {
+	return &staticCloudProvider{mapping: mapping}
+}
+
+func (p *staticCloudProvider) Name() string { return "static" }
+
+func (p *staticCloudProvider) Detect() bool { return len(p.mapping) > 0 }
+
+func (p *staticCloudProvider) SubnetForHwAddr(hwAddr net.HardwareAddr) (Subnet, error) {
+	subnet, ok := p.mapping[hwAddr.String()]
+	if !ok {
+		return Subnet{}, subnetLookupError("no static mapping for hardware address " + hwAddr.String())
+	}
+	return subnet, nil
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCloudProvider struct {
+	name    string
+	detect  bool
+	subnets map[string]Subnet
+	calls   int
+}
+
+func (p *fakeCloudProvider) Name() string { return p.name }
+func (p *fakeCloudProvider) Detect() bool { return p.detect }
+func (p *fakeCloudProvider) SubnetForHwAddr(hwAddr net.HardwareAddr) (Subnet, error) {
+	p.calls++
+	subnet, ok := p.subnets[hwAddr.String()]
+	if !ok {
+		return Subnet{}, subnetLookupError("not found")
+	}
+	return subnet, nil
+}
+
+func TestCloudProviderRegistryTriesInPriorityOrderAndCaches(t *testing.T) {
+	notDetected := &fakeCloudProvider{name: "gcp", detect: false}
+	winner := &fakeCloudProvider{name: "aws", detect: true, subnets: map[string]Subnet{
+		"00:11:22:33:44:55": {Alias: "subnet-123", Region: "us-east-1"},
+	}}
+
+	reg := newCloudProviderRegistry([]CloudProvider{notDetected, winner}, time.Minute, time.Second)
+	hwAddr, err := net.ParseMAC("00:11:22:33:44:55")
+	require.NoError(t, err)
+
+	subnet, err := reg.SubnetForHwAddr(hwAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "subnet-123", subnet.Alias)
+
+	// A second call within the TTL must not hit the provider again.
+	_, err = reg.SubnetForHwAddr(hwAddr)
+	require.NoError(t, err)
+	assert.Equal(t, 1, winner.calls)
+	assert.Equal(t, 0, notDetected.calls)
+}
+
+func TestCloudProviderRegistryNegativeCache(t *testing.T) {
+	p := &fakeCloudProvider{name: "static", detect: true, subnets: map[string]Subnet{}}
+	reg := newCloudProviderRegistry([]CloudProvider{p}, time.Minute, time.Minute)
+	hwAddr, err := net.ParseMAC("00:11:22:33:44:66")
+	require.NoError(t, err)
+
+	_, err = reg.SubnetForHwAddr(hwAddr)
+	assert.Error(t, err)
+	_, err = reg.SubnetForHwAddr(hwAddr)
+	assert.Error(t, err)
+
+	assert.Equal(t, 1, p.calls)
+}
+
+func TestStaticCloudProvider(t *testing.T) {
+	hwAddr, err := net.ParseMAC("aa:bb:cc:dd:ee:ff")
+	require.NoError(t, err)
+
+	p := newStaticCloudProvider(map[string]Subnet{
+		hwAddr.String(): {Alias: "on-prem-42"},
+	})
+
+	assert.True(t, p.Detect())
+	subnet, err := p.SubnetForHwAddr(hwAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "on-prem-42", subnet.Alias)
+
+	other, err := net.ParseMAC("11:22:33:44:55:66")
+	require.NoError(t, err)
+	_, err = p.SubnetForHwAddr(other)
+	assert.Error(t, err)
+}
+
+func TestGCPCloudProviderSubnetForHwAddr(t *testing.T) {
+	hwAddr, err := net.ParseMAC("00:11:22:33:44:55")
+	require.NoError(t, err)
+
+	p := &gcpCloudProvider{fetch: func(path string) (string, error) {
+		switch path {
+		case "/computeMetadata/v1/instance/network-interfaces/by-mac/00:11:22:33:44:55/subnetmask":
+			return "255.255.255.0", nil
+		case "/computeMetadata/v1/instance/zone":
+			return "projects/123/zones/us-central1-a", nil
+		case "/computeMetadata/v1/instance/network-interfaces/by-mac/00:11:22:33:44:55/network":
+			return "projects/123/networks/default", nil
+		}
+		return "", subnetLookupError("unexpected path " + path)
+	}}
+
+	assert.True(t, p.Detect())
+	subnet, err := p.SubnetForHwAddr(hwAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "255.255.255.0", subnet.Alias)
+	assert.Equal(t, "projects/123/zones/us-central1-a", subnet.Zone)
+}
+
+func TestAzureCloudProviderNormalizesMAC(t *testing.T) {
+	hwAddr, err := net.ParseMAC("00:11:22:33:44:55")
+	require.NoError(t, err)
+
+	var seenPath string
+	p := &azureCloudProvider{fetch: func(path string) (string, error) {
+		if path == "/metadata/instance/compute/vmId" {
+			return "vm-1", nil
+		}
+		seenPath = path
+		return "subnet-abc", nil
+	}}
+
+	assert.True(t, p.Detect())
+	subnet, err := p.SubnetForHwAddr(hwAddr)
+	require.NoError(t, err)
+	assert.Equal(t, "subnet-abc", subnet.Alias)
+	assert.Contains(t, seenPath, "001122334455")
+}
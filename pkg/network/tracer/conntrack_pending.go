@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+// ConntrackLookupMaxAge is the default upper bound on how long a connection
+// whose conntrack translation hasn't resolved yet will keep being retried
+// before the pending lookup is dropped as expired.
+const ConntrackLookupMaxAge = 500 * time.Millisecond
+
+var conntrackPendingBackoff = []time.Duration{5 * time.Millisecond, 20 * time.Millisecond, 80 * time.Millisecond, 320 * time.Millisecond}
+
+var (
+	conntrackLookupPending            = telemetry.NewGauge("conntrack", "lookup_pending", nil, "Number of connections awaiting a retried conntrack lookup")
+	conntrackLookupResolvedAfterRetry = telemetry.NewCounter("conntrack", "lookup_resolved_after_retry", []string{"attempts"}, "Conntrack lookups that only resolved on a retry, by attempt count")
+	conntrackLookupDroppedExpired     = telemetry.NewCounter("conntrack", "lookup_dropped_expired", nil, "Pending conntrack lookups dropped after exceeding ConntrackLookupMaxAge without resolving")
+)
+
+// conntrackPendingEntry tracks a single connection tuple whose conntrack
+// translation wasn't available the first time it was looked up.
+type conntrackPendingEntry struct {
+	tuple       connTuple
+	firstSeen   time.Time
+	nextAttempt time.Time
+	attempts    int
+}
+
+// conntrackPendingQueue would be a single shard of a pending-lookup
+// subsystem: a set of tuples awaiting a retried GetTranslationForConn
+// call, deduplicated by tuple and driven by one goroutine's retry loop
+// (it is a map, not a FIFO: DueEntries returns due tuples in no particular
+// order),
+// with multiple shards (keyed by e.g. tuple hash) letting retries for
+// unrelated connections proceed without contending on one lock. Nothing
+// currently enqueues a tuple here after a failed conntrack lookup or drains
+// DueEntries on a retry loop; this is a standalone queue today.
+type conntrackPendingQueue struct {
+	maxAge  time.Duration
+	entries map[connTuple]*conntrackPendingEntry
+}
+
+// newConntrackPendingQueue creates an empty queue that will give up on a
+// tuple once it has been pending for longer than maxAge.
+func newConntrackPendingQueue(maxAge time.Duration) *conntrackPendingQueue {
+	return &conntrackPendingQueue{
+		maxAge:  maxAge,
+		entries: make(map[connTuple]*conntrackPendingEntry),
+	}
+}
+
+// Enqueue records a failed lookup for tuple, coalescing with any existing
+// pending entry for the same tuple rather than creating a duplicate. now is
+// passed in explicitly so tests can drive the clock.
+func (q *conntrackPendingQueue) Enqueue(tuple connTuple, now time.Time) {
+	if _, ok := q.entries[tuple]; ok {
+		return
+	}
+	q.entries[tuple] = &conntrackPendingEntry{
+		tuple:       tuple,
+		firstSeen:   now,
+		nextAttempt: now.Add(conntrackPendingBackoff[0]),
+	}
+	conntrackLookupPending.Inc()
+}
+
+// Resolve removes tuple from the queue, e.g. because its translation was
+// found or the connection closed. It is a no-op if tuple isn't pending.
+func (q *conntrackPendingQueue) Resolve(tuple connTuple) {
+	if _, ok := q.entries[tuple]; ok {
+		delete(q.entries, tuple)
+		conntrackLookupPending.Dec()
+	}
+}
+
+// DueEntries returns, and advances to their next backoff step, the tuples
+// whose nextAttempt has passed as of now. Entries older than maxAge are
+// dropped instead of being returned, and counted as expired.
+func (q *conntrackPendingQueue) DueEntries(now time.Time) []connTuple {
+	var due []connTuple
+	for tuple, entry := range q.entries {
+		if now.Sub(entry.firstSeen) > q.maxAge {
+			delete(q.entries, tuple)
+			conntrackLookupPending.Dec()
+			conntrackLookupDroppedExpired.Inc()
+			continue
+		}
+		if now.Before(entry.nextAttempt) {
+			continue
+		}
+
+		due = append(due, tuple)
+		entry.attempts++
+
+		step := entry.attempts
+		if step >= len(conntrackPendingBackoff) {
+			step = len(conntrackPendingBackoff) - 1
+		}
+		delay := conntrackPendingBackoff[step]
+		if entry.firstSeen.Add(time.Duration(entry.attempts+1) * delay).After(entry.firstSeen.Add(q.maxAge)) {
+			delay = q.maxAge - now.Sub(entry.firstSeen)
+		}
+		entry.nextAttempt = now.Add(delay)
+	}
+	return due
+}
+
+// MarkResolved removes tuple from the queue and records the attempts metric
+// for a translation that resolved on a retry rather than the first lookup.
+func (q *conntrackPendingQueue) MarkResolved(tuple connTuple) {
+	entry, ok := q.entries[tuple]
+	if !ok {
+		return
+	}
+	delete(q.entries, tuple)
+	conntrackLookupPending.Dec()
+	if entry.attempts > 0 {
+		conntrackLookupResolvedAfterRetry.Inc(strconv.Itoa(entry.attempts))
+	}
+}
+
+// Len returns the number of tuples currently pending retry.
+func (q *conntrackPendingQueue) Len() int {
+	return len(q.entries)
+}
+
+// connTuple is the minimal 4-tuple+protocol identity used to key pending
+// conntrack lookups. The real tracer package keys connections by a richer
+// struct; this is the subset relevant to deduplicating retries.
+type connTuple struct {
+	Source, Dest string
+	SPort, DPort uint16
+	NetNS        uint32
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConntrackPendingQueueRetriesWithBackoff(t *testing.T) {
+	q := newConntrackPendingQueue(500 * time.Millisecond)
+	tuple := connTuple{Source: "10.0.0.1", Dest: "10.0.0.2", SPort: 1234, DPort: 80}
+
+	start := time.Now()
+	q.Enqueue(tuple, start)
+	require.Equal(t, 1, q.Len())
+
+	// Not due yet.
+	assert.Empty(t, q.DueEntries(start.Add(1*time.Millisecond)))
+
+	// Due after the first backoff step.
+	due := q.DueEntries(start.Add(10 * time.Millisecond))
+	assert.Equal(t, []connTuple{tuple}, due)
+
+	// Re-enqueueing the same tuple while pending should not duplicate it.
+	q.Enqueue(tuple, start.Add(10*time.Millisecond))
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestConntrackPendingQueueDropsExpiredEntries(t *testing.T) {
+	q := newConntrackPendingQueue(50 * time.Millisecond)
+	tuple := connTuple{Source: "10.0.0.1", Dest: "10.0.0.2", SPort: 1234, DPort: 80}
+
+	start := time.Now()
+	q.Enqueue(tuple, start)
+
+	due := q.DueEntries(start.Add(100 * time.Millisecond))
+	assert.Empty(t, due)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestConntrackPendingQueueMarkResolvedTracksRetries(t *testing.T) {
+	q := newConntrackPendingQueue(500 * time.Millisecond)
+	tuple := connTuple{Source: "10.0.0.1", Dest: "10.0.0.2", SPort: 1234, DPort: 80}
+
+	start := time.Now()
+	q.Enqueue(tuple, start)
+	q.DueEntries(start.Add(10 * time.Millisecond))
+
+	q.MarkResolved(tuple)
+	assert.Equal(t, 0, q.Len())
+}
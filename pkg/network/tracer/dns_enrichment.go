@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"container/list"
+	"net/netip"
+	"time"
+)
+
+// dnsEnrichmentKey identifies a cached DNS answer scoped to the network
+// namespace it was observed in, so two netns resolving the same IP to
+// different hostnames (common with NAT and split-horizon DNS) don't clobber
+// each other.
+type dnsEnrichmentKey struct {
+	NetNS uint32
+	Addr  netip.Addr
+}
+
+// dnsEnrichmentEntry is the most recent hostname a given (netns, addr) pair
+// resolved to, and when.
+type dnsEnrichmentEntry struct {
+	Hostname   string
+	ResolvedAt time.Time
+}
+
+// dnsEnrichmentCache is a bounded LRU that would be populated by the DNS
+// snooper, mapping (netns, resolved IP) to the most recent hostname
+// (CNAME/A/AAAA) that resolved to it, so connection enrichment could look up
+// a TCP flow's destination address here to attach the FQDN a client
+// originally queried. Nothing currently calls Put from the DNS snooper or
+// Lookup from connection enrichment; this is a standalone cache today.
+type dnsEnrichmentCache struct {
+	maxEntries int
+	ttl        time.Duration
+	nowFn      func() time.Time
+
+	ll    *list.List // list of *dnsCacheElem, most-recently-used at the front
+	items map[dnsEnrichmentKey]*list.Element
+}
+
+type dnsCacheElem struct {
+	key   dnsEnrichmentKey
+	entry dnsEnrichmentEntry
+}
+
+// newDNSEnrichmentCache builds a cache holding at most maxEntries mappings,
+// each valid for ttl after being recorded.
+func newDNSEnrichmentCache(maxEntries int, ttl time.Duration) *dnsEnrichmentCache {
+	return &dnsEnrichmentCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		nowFn:      time.Now,
+		ll:         list.New(),
+		items:      make(map[dnsEnrichmentKey]*list.Element),
+	}
+}
+
+// Put records that a DNS answer in netns resolved hostname to addr, evicting
+// the least-recently-used entry if the cache is at capacity.
+func (c *dnsEnrichmentCache) Put(netns uint32, addr netip.Addr, hostname string) {
+	key := dnsEnrichmentKey{NetNS: netns, Addr: addr}
+	now := c.nowFn()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*dnsCacheElem).entry = dnsEnrichmentEntry{Hostname: hostname, ResolvedAt: now}
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	elem := c.ll.PushFront(&dnsCacheElem{key: key, entry: dnsEnrichmentEntry{Hostname: hostname, ResolvedAt: now}})
+	c.items[key] = elem
+
+	if c.ll.Len() > c.maxEntries {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*dnsCacheElem).key)
+		}
+	}
+}
+
+// Lookup returns the hostname most recently resolved to addr within netns,
+// if one exists and hasn't aged past the cache's TTL.
+func (c *dnsEnrichmentCache) Lookup(netns uint32, addr netip.Addr) (string, bool) {
+	key := dnsEnrichmentKey{NetNS: netns, Addr: addr}
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := elem.Value.(*dnsCacheElem).entry
+	if c.nowFn().Sub(entry.ResolvedAt) > c.ttl {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		return "", false
+	}
+
+	c.ll.MoveToFront(elem)
+	return entry.Hostname, true
+}
+
+// Len returns the number of entries currently cached, for tests asserting
+// eviction behavior.
+func (c *dnsEnrichmentCache) Len() int {
+	return c.ll.Len()
+}
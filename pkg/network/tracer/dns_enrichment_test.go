@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSEnrichmentCachePutAndLookup(t *testing.T) {
+	c := newDNSEnrichmentCache(10, time.Minute)
+	addr := netip.MustParseAddr("142.250.1.101")
+
+	c.Put(1, addr, "google.com")
+
+	hostname, ok := c.Lookup(1, addr)
+	assert.True(t, ok)
+	assert.Equal(t, "google.com", hostname)
+}
+
+func TestDNSEnrichmentCachePerNetNSIsolation(t *testing.T) {
+	c := newDNSEnrichmentCache(10, time.Minute)
+	addr := netip.MustParseAddr("10.0.0.5")
+
+	c.Put(1, addr, "internal-a.svc")
+	c.Put(2, addr, "internal-b.svc")
+
+	hostA, ok := c.Lookup(1, addr)
+	assert.True(t, ok)
+	assert.Equal(t, "internal-a.svc", hostA)
+
+	hostB, ok := c.Lookup(2, addr)
+	assert.True(t, ok)
+	assert.Equal(t, "internal-b.svc", hostB)
+
+	_, ok = c.Lookup(3, addr)
+	assert.False(t, ok)
+}
+
+func TestDNSEnrichmentCacheExpiresAfterTTL(t *testing.T) {
+	c := newDNSEnrichmentCache(10, time.Minute)
+	addr := netip.MustParseAddr("8.8.8.8")
+
+	start := time.Now()
+	c.nowFn = func() time.Time { return start }
+	c.Put(1, addr, "dns.google")
+
+	c.nowFn = func() time.Time { return start.Add(2 * time.Minute) }
+	_, ok := c.Lookup(1, addr)
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestDNSEnrichmentCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newDNSEnrichmentCache(2, time.Minute)
+	a1 := netip.MustParseAddr("10.0.0.1")
+	a2 := netip.MustParseAddr("10.0.0.2")
+	a3 := netip.MustParseAddr("10.0.0.3")
+
+	c.Put(1, a1, "a1.example")
+	c.Put(1, a2, "a2.example")
+	// touch a1 so a2 becomes the least-recently-used entry
+	c.Lookup(1, a1)
+	c.Put(1, a3, "a3.example")
+
+	assert.Equal(t, 2, c.Len())
+	_, ok := c.Lookup(1, a2)
+	assert.False(t, ok)
+	_, ok = c.Lookup(1, a1)
+	assert.True(t, ok)
+	_, ok = c.Lookup(1, a3)
+	assert.True(t, ok)
+}
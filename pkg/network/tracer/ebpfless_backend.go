@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import "fmt"
+
+// EbpflessBackend selects the implementation backing the ebpfless tracer
+// path.
+type EbpflessBackend string
+
+const (
+	// EbpflessBackendPcap is today's pcap-style packet capture backend. It
+	// derives ConnectionStats heuristically from captured packets and
+	// cannot distinguish loopback flows where SPort == DPort (see the
+	// self-connect handling TODO in the tracer test suite).
+	EbpflessBackendPcap EbpflessBackend = "pcap"
+
+	// EbpflessBackendNetstack names a planned backend that would run
+	// captured packets through a gVisor pkg/tcpip/stack instance (the
+	// approach Tailscale's wgengine/netstack uses) and derive
+	// ConnectionStats from the stack's own connection tables instead of
+	// packet-level heuristics, fixing self-connect handling and giving a
+	// real bidirectional UDP "assured" state. It is not implemented: the
+	// gVisor dependency isn't vendored into this module, there is no
+	// concrete EbpflessConnectionSource behind this name, and
+	// ParseEbpflessBackend rejects it accordingly. The constant exists so
+	// the follow-up work has a name to land behind.
+	EbpflessBackendNetstack EbpflessBackend = "netstack"
+)
+
+// ParseEbpflessBackend validates a config-supplied backend name, defaulting
+// to EbpflessBackendPcap for an empty string to preserve the existing
+// behavior for configs written before this option existed.
+// EbpflessBackendNetstack is a recognized name but not yet a usable
+// backend, so it's rejected here rather than silently falling back to pcap.
+func ParseEbpflessBackend(name string) (EbpflessBackend, error) {
+	switch EbpflessBackend(name) {
+	case "":
+		return EbpflessBackendPcap, nil
+	case EbpflessBackendPcap:
+		return EbpflessBackendPcap, nil
+	case EbpflessBackendNetstack:
+		return "", fmt.Errorf("ebpfless_backend %q is not implemented yet", name)
+	default:
+		return "", fmt.Errorf("unknown ebpfless_backend %q: must be %q or %q", name, EbpflessBackendPcap, EbpflessBackendNetstack)
+	}
+}
+
+// EbpflessConnectionSource is the interface an ebpfless backend implements
+// to feed captured packets in and read back derived connections. Only a
+// pcap-backed implementation is expected to exist until the netstack
+// backend described by EbpflessBackendNetstack is built.
+type EbpflessConnectionSource interface {
+	// Backend reports which EbpflessBackend this source implements.
+	Backend() EbpflessBackend
+
+	// HandlePacket feeds a single captured packet (as seen on the loopback
+	// or veth capture point) into the backend.
+	HandlePacket(data []byte) error
+}
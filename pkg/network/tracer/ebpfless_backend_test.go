@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEbpflessBackend(t *testing.T) {
+	backend, err := ParseEbpflessBackend("")
+	require.NoError(t, err)
+	assert.Equal(t, EbpflessBackendPcap, backend)
+
+	// netstack is a recognized name but not a usable backend yet.
+	_, err = ParseEbpflessBackend("netstack")
+	assert.Error(t, err)
+
+	_, err = ParseEbpflessBackend("bogus")
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+// mptcpSubflowStats is the per-subflow view an MPTCP-aware tracer would
+// observe directly: one row per 4-tuple, same as a regular TCP connection,
+// plus the Token shared by every subflow of the same logical connection
+// (on Linux, struct mptcp_sock->token). Nothing currently populates Token
+// from a live kprobe; callers construct these rows themselves today.
+type mptcpSubflowStats struct {
+	Tuple     connTuple
+	Token     uint32
+	Direction string
+	SentBytes uint64
+	RecvBytes uint64
+}
+
+// mptcpAggregatedStats is the logical, folded-together connection this
+// package exposes: byte counters summed across every subflow sharing
+// Token, plus (when exposeSubflows is set on the aggregator that produced
+// it) the individual subflow rows for debugging.
+type mptcpAggregatedStats struct {
+	Token     uint32
+	Direction string
+	SentBytes uint64
+	RecvBytes uint64
+	Subflows  []mptcpSubflowStats
+}
+
+// mptcpAggregator folds per-subflow connections sharing a Token into a
+// single logical entry, while still retaining the raw per-subflow rows for
+// debugging when exposeSubflows is set. It is a standalone aggregator: no
+// config knob or tracer code path feeds it real subflow observations yet,
+// and its output isn't surfaced on any exported connection type.
+type mptcpAggregator struct {
+	exposeSubflows bool
+	byToken        map[uint32]*mptcpAggregatedStats
+}
+
+// newMPTCPAggregator builds an aggregator. When exposeSubflows is true,
+// Aggregated retains every subflow's individual stats alongside the summed
+// totals.
+func newMPTCPAggregator(exposeSubflows bool) *mptcpAggregator {
+	return &mptcpAggregator{exposeSubflows: exposeSubflows, byToken: make(map[uint32]*mptcpAggregatedStats)}
+}
+
+// Add folds a single observed subflow into its logical connection. The
+// first subflow seen for a token determines the Direction reported for the
+// whole logical connection, since all subflows of one MPTCP connection
+// share the same app-level Direction even though any given subflow can
+// migrate across paths.
+func (a *mptcpAggregator) Add(subflow mptcpSubflowStats) *mptcpAggregatedStats {
+	agg, ok := a.byToken[subflow.Token]
+	if !ok {
+		agg = &mptcpAggregatedStats{Token: subflow.Token, Direction: subflow.Direction}
+		a.byToken[subflow.Token] = agg
+	}
+
+	agg.SentBytes += subflow.SentBytes
+	agg.RecvBytes += subflow.RecvBytes
+	if a.exposeSubflows {
+		agg.Subflows = append(agg.Subflows, subflow)
+	}
+
+	return agg
+}
+
+// Get returns the aggregated stats for token, if any subflow has been
+// recorded for it.
+func (a *mptcpAggregator) Get(token uint32) (*mptcpAggregatedStats, bool) {
+	agg, ok := a.byToken[token]
+	return agg, ok
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMPTCPAggregatorSumsAcrossSubflows(t *testing.T) {
+	agg := newMPTCPAggregator(false)
+
+	agg.Add(mptcpSubflowStats{
+		Tuple:     connTuple{Source: "10.0.0.1", Dest: "10.0.0.2", SPort: 1000, DPort: 80},
+		Token:     0xdeadbeef,
+		Direction: "outgoing",
+		SentBytes: 100,
+		RecvBytes: 50,
+	})
+	agg.Add(mptcpSubflowStats{
+		Tuple:     connTuple{Source: "10.0.0.3", Dest: "10.0.0.2", SPort: 1001, DPort: 80},
+		Token:     0xdeadbeef,
+		Direction: "outgoing",
+		SentBytes: 200,
+		RecvBytes: 75,
+	})
+
+	got, ok := agg.Get(0xdeadbeef)
+	require.True(t, ok)
+	assert.Equal(t, uint64(300), got.SentBytes)
+	assert.Equal(t, uint64(125), got.RecvBytes)
+	assert.Equal(t, "outgoing", got.Direction)
+	assert.Empty(t, got.Subflows)
+}
+
+func TestMPTCPAggregatorExposesSubflowsWhenConfigured(t *testing.T) {
+	agg := newMPTCPAggregator(true)
+
+	agg.Add(mptcpSubflowStats{Token: 42, SentBytes: 10})
+	agg.Add(mptcpSubflowStats{Token: 42, SentBytes: 20})
+
+	got, ok := agg.Get(42)
+	require.True(t, ok)
+	assert.Len(t, got.Subflows, 2)
+	assert.Equal(t, uint64(30), got.SentBytes)
+}
+
+func TestMPTCPAggregatorUnknownToken(t *testing.T) {
+	agg := newMPTCPAggregator(false)
+	_, ok := agg.Get(999)
+	assert.False(t, ok)
+}
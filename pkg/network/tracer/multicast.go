@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import "net/netip"
+
+// MulticastKind classifies the destination of a UDP datagram, intended to
+// let aggregation treat a fan-out group as a single connection instead of
+// one entry per responding member once that aggregation is wired in.
+type MulticastKind uint8
+
+const (
+	// Unicast is an ordinary point-to-point destination.
+	Unicast MulticastKind = iota
+	// IPv4Multicast is a destination in 224.0.0.0/4.
+	IPv4Multicast
+	// IPv6Multicast is a destination in ff00::/8.
+	IPv6Multicast
+	// IPv6LinkLocalMulticast is a destination in the link-local multicast
+	// scope ff02::/16, a common special case of IPv6Multicast (e.g. mDNS,
+	// router solicitation) worth distinguishing from wider-scoped groups.
+	IPv6LinkLocalMulticast
+	// Broadcast is the IPv4 limited broadcast address 255.255.255.255, or a
+	// subnet-directed broadcast (all host bits set).
+	Broadcast
+)
+
+// String returns the lowercase name used in tags and logs.
+func (k MulticastKind) String() string {
+	switch k {
+	case IPv4Multicast:
+		return "ipv4_multicast"
+	case IPv6Multicast:
+		return "ipv6_multicast"
+	case IPv6LinkLocalMulticast:
+		return "ipv6_link_local_multicast"
+	case Broadcast:
+		return "broadcast"
+	default:
+		return "unicast"
+	}
+}
+
+// ClassifyMulticast determines the MulticastKind of dst, optionally taking
+// the subnet broadcast address for the interface the packet was sent/received
+// on (pass the zero Addr if unknown, e.g. the packet never touched a
+// broadcast-capable interface).
+func ClassifyMulticast(dst netip.Addr, subnetBroadcast netip.Addr) MulticastKind {
+	if !dst.IsValid() {
+		return Unicast
+	}
+
+	if dst.Is4() || dst.Is4In6() {
+		addr4 := dst.As4()
+		if addr4 == [4]byte{255, 255, 255, 255} {
+			return Broadcast
+		}
+		if subnetBroadcast.IsValid() && dst == subnetBroadcast {
+			return Broadcast
+		}
+		if addr4[0]&0xf0 == 0xe0 {
+			return IPv4Multicast
+		}
+		return Unicast
+	}
+
+	if dst.Is6() {
+		addr16 := dst.As16()
+		if addr16[0] != 0xff {
+			return Unicast
+		}
+		if addr16[1] == 0x02 {
+			return IPv6LinkLocalMulticast
+		}
+		return IPv6Multicast
+	}
+
+	return Unicast
+}
+
+// multicastGroupKey identifies the single aggregated connection a sender's
+// datagrams to a given multicast/broadcast group should be folded into,
+// regardless of how many group members end up responding.
+type multicastGroupKey struct {
+	source netip.Addr
+	group  netip.Addr
+	dport  uint16
+}
+
+// multicastAggregator would fold per-receiver multicast "connections"
+// observed by the tracer into one stats entry per (sender, group, dport),
+// so a 200-member multicast group doesn't produce 200 unattributable
+// connection rows. Nothing currently calls Add from a live connection path;
+// this is a standalone aggregator today.
+type multicastAggregator struct {
+	groups map[multicastGroupKey]*multicastGroupStats
+}
+
+// multicastGroupStats accumulates byte/packet counters for a single
+// aggregated (sender, group) entry.
+type multicastGroupStats struct {
+	Kind       MulticastKind
+	SentBytes  uint64
+	SentPkts   uint64
+	Recipients map[netip.Addr]struct{}
+}
+
+func newMulticastAggregator() *multicastAggregator {
+	return &multicastAggregator{groups: make(map[multicastGroupKey]*multicastGroupStats)}
+}
+
+// Add folds a single observed datagram (source -> group:dport, recipient,
+// sentBytes) into the aggregator, returning the up-to-date stats entry for
+// that group.
+func (a *multicastAggregator) Add(source, group netip.Addr, dport uint16, recipient netip.Addr, kind MulticastKind, sentBytes uint64) *multicastGroupStats {
+	key := multicastGroupKey{source: source, group: group, dport: dport}
+	entry, ok := a.groups[key]
+	if !ok {
+		entry = &multicastGroupStats{Kind: kind, Recipients: make(map[netip.Addr]struct{})}
+		a.groups[key] = entry
+	}
+	entry.SentBytes += sentBytes
+	entry.SentPkts++
+	if recipient.IsValid() {
+		entry.Recipients[recipient] = struct{}{}
+	}
+	return entry
+}
+
+// Len returns the number of distinct aggregated (sender, group, dport)
+// entries currently tracked.
+func (a *multicastAggregator) Len() int {
+	return len(a.groups)
+}
@@ -0,0 +1,229 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import "net/netip"
+
+// igmpMessageType and mldMessageType values relevant to membership tracking
+// (RFC 3376 §4 for IGMPv3, RFC 3810 §5.1.3 for MLDv2; IGMPv2/MLDv1 reuse the
+// same report type for a single-group report).
+const (
+	igmpV2MembershipReport = 0x16
+	igmpV3MembershipReport = 0x22
+	mldListenerReport      = 131 // MLDv1, ICMPv6 type 131
+	mldv2ListenerReport    = 143 // MLDv2, ICMPv6 type 143
+)
+
+// multicastMembership records that sock (identified by its local port and
+// PID, since multiple SO_REUSEPORT sockets can share a port) has joined
+// group.
+type multicastMembership struct {
+	Group netip.Addr
+	Port  uint16
+	PID   uint32
+}
+
+// multicastMembershipTracker correlates parsed IGMP/MLD membership reports
+// (see parseIGMPv2Report/parseIGMPv3Report/parseMLDv1Report/
+// parseMLDv2Report) with the (port, pid) of the UDP socket that issued the
+// matching IP_ADD_MEMBERSHIP/IPV6_JOIN_GROUP setsockopt, so inbound
+// multicast traffic can eventually be attributed to the subscribing process
+// instead of reported as unowned. Feeding real skb/netlink data into Join
+// and Leave, and exposing the result on a connection, is not yet wired up;
+// this type is a standalone membership table today.
+type multicastMembershipTracker struct {
+	// membersByGroup maps a multicast group to the set of (port, pid) pairs
+	// that have joined it, supporting one sender fanning out to many local
+	// subscribers (e.g. several worker processes all joined to 224.0.0.169).
+	membersByGroup map[netip.Addr]map[multicastMembershipKey]struct{}
+}
+
+type multicastMembershipKey struct {
+	Port uint16
+	PID  uint32
+}
+
+func newMulticastMembershipTracker() *multicastMembershipTracker {
+	return &multicastMembershipTracker{membersByGroup: make(map[netip.Addr]map[multicastMembershipKey]struct{})}
+}
+
+// Join records that the socket bound to port, owned by pid, has joined
+// group via IP_ADD_MEMBERSHIP/IPV6_JOIN_GROUP.
+func (t *multicastMembershipTracker) Join(group netip.Addr, port uint16, pid uint32) {
+	key := multicastMembershipKey{Port: port, PID: pid}
+	members, ok := t.membersByGroup[group]
+	if !ok {
+		members = make(map[multicastMembershipKey]struct{})
+		t.membersByGroup[group] = members
+	}
+	members[key] = struct{}{}
+}
+
+// Leave removes a previously recorded membership, e.g. on
+// IP_DROP_MEMBERSHIP or socket close.
+func (t *multicastMembershipTracker) Leave(group netip.Addr, port uint16, pid uint32) {
+	members, ok := t.membersByGroup[group]
+	if !ok {
+		return
+	}
+	delete(members, multicastMembershipKey{Port: port, PID: pid})
+	if len(members) == 0 {
+		delete(t.membersByGroup, group)
+	}
+}
+
+// MembersOf returns the (port, pid) pairs currently subscribed to group, in
+// no particular order.
+func (t *multicastMembershipTracker) MembersOf(group netip.Addr) []multicastMembershipKey {
+	members := t.membersByGroup[group]
+	out := make([]multicastMembershipKey, 0, len(members))
+	for k := range members {
+		out = append(out, k)
+	}
+	return out
+}
+
+// igmpMembershipReport is a single group record parsed out of an
+// IGMPv2/IGMPv3 membership report.
+type igmpMembershipReport struct {
+	Type  byte
+	Group netip.Addr
+}
+
+// igmpGroupRecordType identifies an IGMPv3 group record's record type
+// (RFC 3376 §4.2.12), reused here for MLDv2 since both wire formats share
+// the same record type numbering.
+type igmpGroupRecordType byte
+
+const (
+	igmpModeIsInclude   igmpGroupRecordType = 1
+	igmpModeIsExclude   igmpGroupRecordType = 2
+	igmpChangeToInclude igmpGroupRecordType = 3
+	igmpChangeToExclude igmpGroupRecordType = 4
+	igmpAllowNewSources igmpGroupRecordType = 5
+	igmpBlockOldSources igmpGroupRecordType = 6
+)
+
+// igmpv3GroupRecord is a single group record within an IGMPv3/MLDv2
+// membership report: the group being reported on, the filter-mode/source
+// change this record describes, and the source addresses it carries.
+type igmpv3GroupRecord struct {
+	RecordType igmpGroupRecordType
+	Group      netip.Addr
+	Sources    []netip.Addr
+}
+
+// parseIGMPv2Report parses the fixed 8-byte IGMPv2 membership report format:
+// type(1) max_resp_time(1) checksum(2) group_address(4).
+func parseIGMPv2Report(buf []byte) (igmpMembershipReport, bool) {
+	if len(buf) < 8 || buf[0] != igmpV2MembershipReport {
+		return igmpMembershipReport{}, false
+	}
+	addr := netip.AddrFrom4([4]byte{buf[4], buf[5], buf[6], buf[7]})
+	return igmpMembershipReport{Type: buf[0], Group: addr}, true
+}
+
+// parseMLDv1Report parses the fixed ICMPv6 MLDv1 listener-report format:
+// type(1) code(1) checksum(2) max_resp_delay(2) reserved(2)
+// multicast_address(16).
+func parseMLDv1Report(buf []byte) (igmpMembershipReport, bool) {
+	if len(buf) < 24 || buf[0] != mldListenerReport {
+		return igmpMembershipReport{}, false
+	}
+	var addrBytes [16]byte
+	copy(addrBytes[:], buf[8:24])
+	return igmpMembershipReport{Type: buf[0], Group: netip.AddrFrom16(addrBytes)}, true
+}
+
+// parseIGMPv3Report parses an IGMPv3 membership report (RFC 3376 §4.2): an
+// 8-byte header (type(1) reserved(1) checksum(2) reserved(2)
+// num_group_records(2)) followed by that many variable-length group
+// records (record_type(1) aux_data_len(1) num_sources(2)
+// multicast_address(4) source_addresses(4*num_sources) aux_data(4*aux_data_len)).
+func parseIGMPv3Report(buf []byte) ([]igmpv3GroupRecord, bool) {
+	if len(buf) < 8 || buf[0] != igmpV3MembershipReport {
+		return nil, false
+	}
+	numRecords := int(buf[6])<<8 | int(buf[7])
+	pos := 8
+
+	records := make([]igmpv3GroupRecord, 0, numRecords)
+	for i := 0; i < numRecords; i++ {
+		if pos+8 > len(buf) {
+			return nil, false
+		}
+		recordType := igmpGroupRecordType(buf[pos])
+		auxDataLen := int(buf[pos+1])
+		numSources := int(buf[pos+2])<<8 | int(buf[pos+3])
+		group := netip.AddrFrom4([4]byte{buf[pos+4], buf[pos+5], buf[pos+6], buf[pos+7]})
+		pos += 8
+
+		sources := make([]netip.Addr, 0, numSources)
+		for s := 0; s < numSources; s++ {
+			if pos+4 > len(buf) {
+				return nil, false
+			}
+			sources = append(sources, netip.AddrFrom4([4]byte{buf[pos], buf[pos+1], buf[pos+2], buf[pos+3]}))
+			pos += 4
+		}
+		pos += auxDataLen * 4
+		if pos > len(buf) {
+			return nil, false
+		}
+
+		records = append(records, igmpv3GroupRecord{RecordType: recordType, Group: group, Sources: sources})
+	}
+
+	return records, true
+}
+
+// parseMLDv2Report parses an MLDv2 listener report (RFC 3810 §5.2): an
+// 8-byte ICMPv6 header (type(1) code(1) checksum(2) reserved(2)
+// nr_of_mcast_address_records(2)) followed by that many variable-length
+// group records (record_type(1) aux_data_len(1) number_of_sources(2)
+// multicast_address(16) source_addresses(16*number_of_sources)
+// aux_data(4*aux_data_len)) — the same shape as parseIGMPv3Report, just
+// with 16-byte IPv6 addresses in place of 4-byte IPv4 ones.
+func parseMLDv2Report(buf []byte) ([]igmpv3GroupRecord, bool) {
+	if len(buf) < 8 || buf[0] != mldv2ListenerReport {
+		return nil, false
+	}
+	numRecords := int(buf[6])<<8 | int(buf[7])
+	pos := 8
+
+	records := make([]igmpv3GroupRecord, 0, numRecords)
+	for i := 0; i < numRecords; i++ {
+		if pos+20 > len(buf) {
+			return nil, false
+		}
+		recordType := igmpGroupRecordType(buf[pos])
+		auxDataLen := int(buf[pos+1])
+		numSources := int(buf[pos+2])<<8 | int(buf[pos+3])
+		var groupBytes [16]byte
+		copy(groupBytes[:], buf[pos+4:pos+20])
+		group := netip.AddrFrom16(groupBytes)
+		pos += 20
+
+		sources := make([]netip.Addr, 0, numSources)
+		for s := 0; s < numSources; s++ {
+			if pos+16 > len(buf) {
+				return nil, false
+			}
+			var srcBytes [16]byte
+			copy(srcBytes[:], buf[pos:pos+16])
+			sources = append(sources, netip.AddrFrom16(srcBytes))
+			pos += 16
+		}
+		pos += auxDataLen * 4
+		if pos > len(buf) {
+			return nil, false
+		}
+
+		records = append(records, igmpv3GroupRecord{RecordType: recordType, Group: group, Sources: sources})
+	}
+
+	return records, true
+}
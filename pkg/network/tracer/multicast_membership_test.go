@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMulticastMembershipTrackerJoinAndLeave(t *testing.T) {
+	tracker := newMulticastMembershipTracker()
+	group := netip.MustParseAddr("224.0.0.169")
+
+	tracker.Join(group, 9000, 100)
+	tracker.Join(group, 9001, 200)
+	tracker.Join(group, 9002, 300)
+
+	members := tracker.MembersOf(group)
+	assert.Len(t, members, 3)
+
+	tracker.Leave(group, 9001, 200)
+	members = tracker.MembersOf(group)
+	assert.Len(t, members, 2)
+	assert.NotContains(t, members, multicastMembershipKey{Port: 9001, PID: 200})
+}
+
+func TestMulticastMembershipTrackerLeaveLastMemberRemovesGroup(t *testing.T) {
+	tracker := newMulticastMembershipTracker()
+	group := netip.MustParseAddr("ff02::3")
+
+	tracker.Join(group, 9000, 100)
+	tracker.Leave(group, 9000, 100)
+
+	assert.Empty(t, tracker.MembersOf(group))
+	_, ok := tracker.membersByGroup[group]
+	assert.False(t, ok)
+}
+
+func TestParseIGMPv2Report(t *testing.T) {
+	buf := []byte{igmpV2MembershipReport, 0x00, 0x00, 0x00, 224, 0, 0, 169}
+	report, ok := parseIGMPv2Report(buf)
+	require.True(t, ok)
+	assert.Equal(t, netip.MustParseAddr("224.0.0.169"), report.Group)
+
+	_, ok = parseIGMPv2Report([]byte{0x11, 0, 0, 0, 224, 0, 0, 169})
+	assert.False(t, ok)
+}
+
+func TestParseMLDv1Report(t *testing.T) {
+	buf := make([]byte, 24)
+	buf[0] = mldListenerReport
+	group := netip.MustParseAddr("ff02::3")
+	groupBytes := group.As16()
+	copy(buf[8:24], groupBytes[:])
+
+	report, ok := parseMLDv1Report(buf)
+	require.True(t, ok)
+	assert.Equal(t, group, report.Group)
+}
+
+func TestParseIGMPv3Report(t *testing.T) {
+	group1 := netip.MustParseAddr("224.0.0.169")
+	source := netip.MustParseAddr("10.0.0.1")
+	group2 := netip.MustParseAddr("239.1.2.3")
+
+	buf := []byte{igmpV3MembershipReport, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02}
+	// record 1: MODE_IS_EXCLUDE, no aux data, one source
+	buf = append(buf, byte(igmpModeIsExclude), 0x00, 0x00, 0x01)
+	buf = append(buf, group1.As4()[:]...)
+	buf = append(buf, source.As4()[:]...)
+	// record 2: CHANGE_TO_INCLUDE, no aux data, no sources
+	buf = append(buf, byte(igmpChangeToInclude), 0x00, 0x00, 0x00)
+	buf = append(buf, group2.As4()[:]...)
+
+	records, ok := parseIGMPv3Report(buf)
+	require.True(t, ok)
+	require.Len(t, records, 2)
+	assert.Equal(t, igmpv3GroupRecord{RecordType: igmpModeIsExclude, Group: group1, Sources: []netip.Addr{source}}, records[0])
+	assert.Equal(t, igmpv3GroupRecord{RecordType: igmpChangeToInclude, Group: group2, Sources: []netip.Addr{}}, records[1])
+
+	_, ok = parseIGMPv3Report([]byte{igmpV2MembershipReport, 0, 0, 0, 0, 0, 0, 0})
+	assert.False(t, ok)
+
+	_, ok = parseIGMPv3Report([]byte{igmpV3MembershipReport, 0, 0, 0, 0, 0, 0, 1})
+	assert.False(t, ok)
+}
+
+func TestParseMLDv2Report(t *testing.T) {
+	group := netip.MustParseAddr("ff02::3")
+	source := netip.MustParseAddr("fe80::1")
+
+	buf := []byte{mldv2ListenerReport, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}
+	buf = append(buf, byte(igmpModeIsInclude), 0x00, 0x00, 0x01)
+	groupBytes := group.As16()
+	buf = append(buf, groupBytes[:]...)
+	sourceBytes := source.As16()
+	buf = append(buf, sourceBytes[:]...)
+
+	records, ok := parseMLDv2Report(buf)
+	require.True(t, ok)
+	require.Len(t, records, 1)
+	assert.Equal(t, igmpv3GroupRecord{RecordType: igmpModeIsInclude, Group: group, Sources: []netip.Addr{source}}, records[0])
+
+	_, ok = parseMLDv2Report([]byte{mldListenerReport, 0, 0, 0, 0, 0, 0, 0})
+	assert.False(t, ok)
+
+	_, ok = parseMLDv2Report([]byte{mldv2ListenerReport, 0, 0, 0, 0, 0, 0, 1})
+	assert.False(t, ok)
+}
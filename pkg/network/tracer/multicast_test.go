@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyMulticast(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  string
+		want MulticastKind
+	}{
+		{"unicast v4", "10.1.2.3", Unicast},
+		{"multicast v4", "224.0.0.169", IPv4Multicast},
+		{"limited broadcast", "255.255.255.255", Broadcast},
+		{"unicast v6", "2001:db8::1", Unicast},
+		{"multicast v6 global", "ff0e::1", IPv6Multicast},
+		{"multicast v6 link-local", "ff02::3", IPv6LinkLocalMulticast},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := netip.MustParseAddr(tt.dst)
+			assert.Equal(t, tt.want, ClassifyMulticast(dst, netip.Addr{}))
+		})
+	}
+}
+
+func TestClassifyMulticastSubnetBroadcast(t *testing.T) {
+	dst := netip.MustParseAddr("10.1.2.255")
+	assert.Equal(t, Unicast, ClassifyMulticast(dst, netip.Addr{}))
+	assert.Equal(t, Broadcast, ClassifyMulticast(dst, dst))
+}
+
+func TestMulticastAggregatorFoldsMembersIntoOneEntry(t *testing.T) {
+	agg := newMulticastAggregator()
+	source := netip.MustParseAddr("10.0.0.1")
+	group := netip.MustParseAddr("224.0.0.169")
+
+	members := []string{"10.0.0.2", "10.0.0.3", "10.0.0.4"}
+	for _, m := range members {
+		agg.Add(source, group, 9000, netip.MustParseAddr(m), IPv4Multicast, 100)
+	}
+
+	assert.Equal(t, 1, agg.Len())
+	entry := agg.groups[multicastGroupKey{source: source, group: group, dport: 9000}]
+	assert.Equal(t, uint64(300), entry.SentBytes)
+	assert.Equal(t, uint64(3), entry.SentPkts)
+	assert.Len(t, entry.Recipients, 3)
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+// recvmsgFlags mirrors the subset of Linux's msg->msg_flags (as read back at
+// kretprobe time from the in-kernel msghdr, not the flags the caller passed
+// in) that matter for not over/undercounting RecvBytes.
+type recvmsgFlags uint32
+
+const (
+	// recvmsgFlagPeek is set when the call was a MSG_PEEK read: bytes were
+	// copied out but left on the socket's receive queue, so they must not
+	// be counted now (they'll be counted for real on the subsequent
+	// non-peeking read that actually consumes them).
+	recvmsgFlagPeek recvmsgFlags = 1 << iota
+	// recvmsgFlagTrunc is set on a datagram socket when the read buffer was
+	// too small for the full datagram: the kernel's return value is the
+	// buffer-limited copy length, but msg_flags|MSG_TRUNC together with the
+	// real datagram length (read separately) tells us the true size to
+	// count.
+	recvmsgFlagTrunc
+)
+
+// recvmsgObservation is what the kretprobe hands userspace for a single
+// tcp_recvmsg/udp_recvmsg return: how many bytes the call reported copying,
+// the flags the kernel set on return, and — for MSG_TRUNC datagrams only —
+// the full on-wire datagram length.
+type recvmsgObservation struct {
+	CopiedBytes   int64
+	Flags         recvmsgFlags
+	DatagramBytes int64 // only meaningful when Flags&recvmsgFlagTrunc != 0
+}
+
+// AccountedBytes returns how many bytes a single recvmsg observation should
+// contribute to a RecvBytes counter:
+//
+//   - MSG_PEEK reads contribute zero: the data is still queued and will be
+//     counted for real when a normal read later consumes it.
+//   - MSG_TRUNC reads on a datagram socket contribute the full on-wire
+//     datagram size, not the (possibly much smaller) buffer-limited copy
+//     length the syscall returns.
+//   - An interrupted MSG_WAITALL (EINTR partway through) still reports
+//     whatever CopiedBytes made it into the buffer before the signal landed,
+//     which is exactly what should be counted — no special-casing needed
+//     beyond not treating CopiedBytes as "negative on error".
+//
+// Nothing currently calls AccountedBytes from a live recvmsg kretprobe path
+// or folds its result into ConnectionStats; this is a standalone accounting
+// helper today.
+func (o recvmsgObservation) AccountedBytes() int64 {
+	if o.Flags&recvmsgFlagPeek != 0 {
+		return 0
+	}
+	if o.Flags&recvmsgFlagTrunc != 0 {
+		return o.DatagramBytes
+	}
+	if o.CopiedBytes < 0 {
+		return 0
+	}
+	return o.CopiedBytes
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecvmsgAccountedBytesPeekDoesNotDoubleCount(t *testing.T) {
+	peek1 := recvmsgObservation{CopiedBytes: 6, Flags: recvmsgFlagPeek}
+	peek2 := recvmsgObservation{CopiedBytes: 6, Flags: recvmsgFlagPeek}
+	real := recvmsgObservation{CopiedBytes: 6}
+
+	total := peek1.AccountedBytes() + peek2.AccountedBytes() + real.AccountedBytes()
+	assert.EqualValues(t, 6, total)
+}
+
+func TestRecvmsgAccountedBytesTruncUsesDatagramLength(t *testing.T) {
+	obs := recvmsgObservation{CopiedBytes: 100, Flags: recvmsgFlagTrunc, DatagramBytes: 2000}
+	assert.EqualValues(t, 2000, obs.AccountedBytes())
+}
+
+func TestRecvmsgAccountedBytesPartialWaitallCountsDeliveredBytes(t *testing.T) {
+	// An EINTR mid-MSG_WAITALL still reports however many bytes made it in
+	// before the signal, which must be counted as-is.
+	obs := recvmsgObservation{CopiedBytes: 4}
+	assert.EqualValues(t, 4, obs.AccountedBytes())
+}
+
+func TestRecvmsgAccountedBytesNegativeReturnCountsNothing(t *testing.T) {
+	obs := recvmsgObservation{CopiedBytes: -1}
+	assert.EqualValues(t, 0, obs.AccountedBytes())
+}
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+// socketCookie is a kernel-assigned unique identifier for a struct sock*,
+// analogous to what bpf_get_socket_cookie returns. It's stable across the
+// socket's lifetime, unlike its memory address, which the allocator can
+// reuse.
+type socketCookie uint64
+
+// reusePortPIDTracker records, at accept(2) time, which PID actually
+// accepted a given connection on a SO_REUSEPORT listener. Without this, a
+// flow's PID would have to be inferred from the listening socket, which
+// with SO_REUSEPORT is shared by every worker process and would
+// misattribute every connection to whichever worker happened to bind last.
+//
+// An accept-side kprobe on inet_csk_accept would record current->pid
+// against the newly accepted socket's cookie via RecordAccept; nothing
+// currently calls RecordAccept from a live kprobe, and no PID-resolution
+// path in this package consults this tracker yet. It is a standalone
+// lookup table today.
+type reusePortPIDTracker struct {
+	pidByCookie map[socketCookie]uint32
+}
+
+func newReusePortPIDTracker() *reusePortPIDTracker {
+	return &reusePortPIDTracker{pidByCookie: make(map[socketCookie]uint32)}
+}
+
+// RecordAccept records that pid called accept(2) and was handed the socket
+// identified by cookie.
+func (r *reusePortPIDTracker) RecordAccept(cookie socketCookie, pid uint32) {
+	r.pidByCookie[cookie] = pid
+}
+
+// PIDFor returns the PID that accepted the socket identified by cookie, if
+// recorded.
+func (r *reusePortPIDTracker) PIDFor(cookie socketCookie) (uint32, bool) {
+	pid, ok := r.pidByCookie[cookie]
+	return pid, ok
+}
+
+// Forget drops the recorded PID for cookie, e.g. once the corresponding
+// connection has been closed and reported.
+func (r *reusePortPIDTracker) Forget(cookie socketCookie) {
+	delete(r.pidByCookie, cookie)
+}
+
+// AttributedPID resolves the PID that should be reported for a connection
+// accepted from a SO_REUSEPORT listener: the PID recorded at accept time if
+// known, falling back to the listener's own PID (listenerPID) for sockets
+// accepted before accept-side tracking was wired up, e.g. right after
+// startup.
+func (r *reusePortPIDTracker) AttributedPID(cookie socketCookie, listenerPID uint32) uint32 {
+	if pid, ok := r.PIDFor(cookie); ok {
+		return pid
+	}
+	return listenerPID
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReusePortAttribution asserts that, for N listener sockets sharing a
+// port via SO_REUSEPORT, each accepted connection is attributed to the
+// worker PID that actually called accept(2) rather than whichever worker's
+// listener happened to bind last.
+func TestReusePortAttribution(t *testing.T) {
+	tracker := newReusePortPIDTracker()
+
+	const listenerPID = uint32(100) // PID of the last worker to bind()
+	workers := []uint32{201, 202, 203, 204}
+
+	clientCookies := make([]socketCookie, 0, len(workers)*4)
+	wantPID := make(map[socketCookie]uint32)
+
+	var cookie socketCookie
+	for _, pid := range workers {
+		for i := 0; i < 4; i++ {
+			cookie++
+			tracker.RecordAccept(cookie, pid)
+			clientCookies = append(clientCookies, cookie)
+			wantPID[cookie] = pid
+		}
+	}
+
+	for _, c := range clientCookies {
+		assert.Equal(t, wantPID[c], tracker.AttributedPID(c, listenerPID))
+	}
+}
+
+func TestReusePortAttributionFallsBackToListenerPID(t *testing.T) {
+	tracker := newReusePortPIDTracker()
+	assert.Equal(t, uint32(42), tracker.AttributedPID(socketCookie(1), 42))
+}
+
+func TestReusePortAttributionForget(t *testing.T) {
+	tracker := newReusePortPIDTracker()
+	tracker.RecordAccept(socketCookie(1), 99)
+	tracker.Forget(socketCookie(1))
+
+	_, ok := tracker.PIDFor(socketCookie(1))
+	assert.False(t, ok)
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package tracer
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// TestSpliceRegression mirrors the existing sendfile regression test: it
+// sets up a TCP source and sink with a pipe as the pump, calls splice()
+// twice (source -> pipe, pipe -> sink) per iteration as nginx/HAProxy-style
+// TCP-to-TCP forwarding does, and asserts the accountant sees the correct
+// byte counts on both legs.
+func TestSpliceRegression(t *testing.T) {
+	const payload = "the quick brown fox jumps over the lazy dog"
+
+	srcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer srcListener.Close()
+
+	sinkListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer sinkListener.Close()
+
+	srcConnCh := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := srcListener.Accept()
+		srcConnCh <- conn
+	}()
+	srcClient, err := net.Dial("tcp", srcListener.Addr().String())
+	require.NoError(t, err)
+	defer srcClient.Close()
+	srcServer := <-srcConnCh
+	defer srcServer.Close()
+
+	sinkConnCh := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := sinkListener.Accept()
+		sinkConnCh <- conn
+	}()
+	sinkClient, err := net.Dial("tcp", sinkListener.Addr().String())
+	require.NoError(t, err)
+	defer sinkClient.Close()
+	sinkServer := <-sinkConnCh
+	defer sinkServer.Close()
+
+	go func() {
+		_, _ = srcClient.Write([]byte(payload))
+	}()
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	accountant := newSpliceByteAccountant()
+
+	srcFile, err := srcServer.(*net.TCPConn).File()
+	require.NoError(t, err)
+	defer srcFile.Close()
+	sinkFile, err := sinkServer.(*net.TCPConn).File()
+	require.NoError(t, err)
+	defer sinkFile.Close()
+
+	n1, err := unix.Splice(int(srcFile.Fd()), nil, int(w.Fd()), nil, len(payload), 0)
+	require.NoError(t, err)
+	accountant.RecordRecv(spliceTransfer{Syscall: spliceSyscallSplice, Bytes: n1})
+
+	n2, err := unix.Splice(int(r.Fd()), nil, int(sinkFile.Fd()), nil, int(n1), 0)
+	require.NoError(t, err)
+	accountant.RecordSend(spliceTransfer{Syscall: spliceSyscallSplice, Bytes: n2})
+
+	buf := make([]byte, len(payload))
+	_, err = io.ReadFull(sinkClient, buf)
+	require.NoError(t, err)
+	require.Equal(t, payload, string(buf))
+
+	require.EqualValues(t, len(payload), accountant.RecvBytes(spliceSyscallSplice))
+	require.EqualValues(t, len(payload), accountant.SentBytes(spliceSyscallSplice))
+}
+
+// TestSpliceError mirrors TestSendfileError: a failed splice (here, an
+// invalid file descriptor triggering EINVAL/EBADF) must not inflate the
+// accountant's byte counters even though the syscall still "returns" in the
+// sense of giving us an error to record.
+func TestSpliceError(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	accountant := newSpliceByteAccountant()
+
+	// Splicing from the pipe's write end (wrong direction) to itself is
+	// rejected by the kernel.
+	_, err = unix.Splice(int(w.Fd()), nil, int(w.Fd()), nil, 10, 0)
+	require.Error(t, err)
+	accountant.RecordSend(spliceTransfer{Syscall: spliceSyscallSplice, Bytes: 0, Failed: true})
+
+	require.Zero(t, accountant.SentBytes(spliceSyscallSplice))
+	require.Zero(t, accountant.RecvBytes(spliceSyscallSplice))
+}
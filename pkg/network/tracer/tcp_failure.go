@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+// TCP failure errno codes (104 = ECONNRESET, 110 = ETIMEDOUT,
+// 111 = ECONNREFUSED, the ones a tracer's BPF programs would most commonly
+// observe), plus the ones this file adds.
+const (
+	errnoECONNRESET    = 104
+	errnoEHOSTUNREACH  = 113
+	errnoENETUNREACH   = 101
+	errnoEADDRNOTAVAIL = 99
+	errnoETIMEDOUT     = 110
+	errnoECONNREFUSED  = 111
+
+	// synSynRetransmitExhausted is a synthesized code (outside the errno
+	// space, which tops out at 133 on Linux) for a SYN that was
+	// retransmitted tcp_retries2 times with no SYN-ACK: distinct from a
+	// generic ETIMEDOUT because it's specifically pre-establishment and
+	// diagnostically more useful ("nothing answered the SYN" vs. "an
+	// established connection went quiet").
+	synSynRetransmitExhausted = 256
+	// rstAfterFin is a synthesized code for a peer that sent RST after
+	// already sending FIN — a protocol violation some misbehaving stacks
+	// produce, distinguishable from an ordinary mid-stream reset.
+	rstAfterFin = 257
+	// keepaliveDrop is a synthesized code for a connection torn down
+	// because TCP keepalive probes went unanswered.
+	keepaliveDrop = 258
+)
+
+// FailureReason distinguishes where in a connection's lifecycle a failure
+// occurred, so downstream consumers can separate "nothing ever connected"
+// from "a previously working connection broke". Nothing currently exposes
+// a FailureReason on a connection; classifyTCPFailure is a standalone
+// classifier today.
+type FailureReason int
+
+const (
+	// FailureReasonNone means the connection didn't fail.
+	FailureReasonNone FailureReason = iota
+	// FailureReasonPreEstablishment covers failures during the SYN phase:
+	// the handshake never completed.
+	FailureReasonPreEstablishment
+	// FailureReasonMidStream covers failures after data had already
+	// flowed: an established connection was later reset or dropped.
+	FailureReasonMidStream
+)
+
+// tcpFailureCode identifies one of the errno/synthesized failure codes this
+// file recognizes.
+type tcpFailureCode uint16
+
+// classifyTCPFailure maps a raw failure code (an errno a tracer's
+// tcp_done/tcp_set_state kprobes would report, or one of the synthesized
+// codes above a tracer's inet_csk_reqsk_queue_hash_add-family kprobes
+// would report) to a FailureReason.
+func classifyTCPFailure(code tcpFailureCode) FailureReason {
+	switch code {
+	case errnoECONNREFUSED, synSynRetransmitExhausted:
+		return FailureReasonPreEstablishment
+	case errnoECONNRESET, errnoETIMEDOUT, errnoEHOSTUNREACH, errnoENETUNREACH, errnoEADDRNOTAVAIL, rstAfterFin, keepaliveDrop:
+		return FailureReasonMidStream
+	default:
+		return FailureReasonNone
+	}
+}
+
+// tcpFailureCounters accumulates observed failures keyed by their raw code.
+// It isn't wired to any exported connection's counters yet; it's a
+// standalone accumulator today.
+type tcpFailureCounters struct {
+	counts map[tcpFailureCode]uint64
+}
+
+func newTCPFailureCounters() *tcpFailureCounters {
+	return &tcpFailureCounters{counts: make(map[tcpFailureCode]uint64)}
+}
+
+// Record folds a single observed failure into the counters.
+func (c *tcpFailureCounters) Record(code tcpFailureCode) {
+	c.counts[code]++
+}
+
+// Count returns how many times code has been observed.
+func (c *tcpFailureCounters) Count(code tcpFailureCode) uint64 {
+	return c.counts[code]
+}
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyTCPFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		code tcpFailureCode
+		want FailureReason
+	}{
+		{"connection refused", errnoECONNREFUSED, FailureReasonPreEstablishment},
+		{"syn retransmit exhausted", synSynRetransmitExhausted, FailureReasonPreEstablishment},
+		{"connection reset", errnoECONNRESET, FailureReasonMidStream},
+		{"timed out", errnoETIMEDOUT, FailureReasonMidStream},
+		{"host unreachable", errnoEHOSTUNREACH, FailureReasonMidStream},
+		{"net unreachable", errnoENETUNREACH, FailureReasonMidStream},
+		{"addr not available", errnoEADDRNOTAVAIL, FailureReasonMidStream},
+		{"rst after fin", rstAfterFin, FailureReasonMidStream},
+		{"keepalive drop", keepaliveDrop, FailureReasonMidStream},
+		{"unknown code", tcpFailureCode(9999), FailureReasonNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyTCPFailure(tt.code))
+		})
+	}
+}
+
+func TestTCPFailureCountersRecordsEachErrnoIndependently(t *testing.T) {
+	counters := newTCPFailureCounters()
+
+	counters.Record(errnoEHOSTUNREACH)
+	counters.Record(errnoEHOSTUNREACH)
+	counters.Record(errnoENETUNREACH)
+	counters.Record(rstAfterFin)
+
+	assert.EqualValues(t, 2, counters.Count(errnoEHOSTUNREACH))
+	assert.EqualValues(t, 1, counters.Count(errnoENETUNREACH))
+	assert.EqualValues(t, 1, counters.Count(rstAfterFin))
+	assert.EqualValues(t, 0, counters.Count(errnoEADDRNOTAVAIL))
+}
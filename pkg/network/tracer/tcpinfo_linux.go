@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package tracer
+
+// TCPInfoStats mirrors the subset of Linux's struct tcp_info (as returned by
+// getsockopt(TCP_INFO)) that a tracer could surface beyond the long-standing
+// RTT/RTTVar/Retransmits trio. Fields that a given kernel doesn't populate
+// (e.g. DeliveryRate/BytesAcked on kernels < 4.6) are left at zero rather than
+// guessed at. Nothing currently calls parseTCPInfo from a live getsockopt
+// path, no ConnectionStats field holds a TCPInfoStats, and there is no
+// config knob gating collection; this file is a standalone decoder today.
+type TCPInfoStats struct {
+	MinRTT        uint32
+	SndCwnd       uint32
+	RcvSsthresh   uint32
+	BytesAcked    uint64
+	BytesReceived uint64
+	BytesRetrans  uint32
+	TotalRetrans  uint32
+	SegsOut       uint32
+	SegsIn        uint32
+	PacingRate    uint64
+	DeliveryRate  uint64
+	NotsentBytes  uint32
+	ReordSeen     uint32
+	Lost          uint32
+}
+
+// tcpInfoFieldOffsets describes where each TCPInfoStats field lives in the
+// raw getsockopt(TCP_INFO) buffer on the running kernel. Offsets differ
+// across kernel versions because struct tcp_info has grown several times.
+// An eBPF-based tracer could resolve these via CO-RE relocations against
+// struct tcp_sock, falling back to an offset table like this one on
+// kernels where BTF isn't available; nothing currently populates or
+// consults a tcpInfoFieldOffsets value outside this file's own tests.
+type tcpInfoFieldOffsets struct {
+	minRTT        int
+	sndCwnd       int
+	rcvSsthresh   int
+	bytesAcked    int
+	bytesReceived int
+	bytesRetrans  int
+	totalRetrans  int
+	segsOut       int
+	segsIn        int
+	pacingRate    int
+	deliveryRate  int
+	notsentBytes  int
+	reordSeen     int
+	lost          int
+}
+
+// parseTCPInfo decodes a raw getsockopt(TCP_INFO) buffer into a
+// TCPInfoStats using the supplied offsets, skipping any field whose offset
+// lies beyond the end of buf (older kernels return a shorter struct).
+// It never panics on a short buffer: unresolved fields stay zero.
+func parseTCPInfo(buf []byte, off tcpInfoFieldOffsets) TCPInfoStats {
+	var stats TCPInfoStats
+
+	readU32 := func(offset int) uint32 {
+		if offset < 0 || offset+4 > len(buf) {
+			return 0
+		}
+		return uint32(buf[offset]) | uint32(buf[offset+1])<<8 | uint32(buf[offset+2])<<16 | uint32(buf[offset+3])<<24
+	}
+	readU64 := func(offset int) uint64 {
+		if offset < 0 || offset+8 > len(buf) {
+			return 0
+		}
+		lo := uint64(readU32(offset))
+		hi := uint64(readU32(offset + 4))
+		return lo | hi<<32
+	}
+
+	stats.MinRTT = readU32(off.minRTT)
+	stats.SndCwnd = readU32(off.sndCwnd)
+	stats.RcvSsthresh = readU32(off.rcvSsthresh)
+	stats.BytesAcked = readU64(off.bytesAcked)
+	stats.BytesReceived = readU64(off.bytesReceived)
+	stats.BytesRetrans = readU32(off.bytesRetrans)
+	stats.TotalRetrans = readU32(off.totalRetrans)
+	stats.SegsOut = readU32(off.segsOut)
+	stats.SegsIn = readU32(off.segsIn)
+	stats.PacingRate = readU64(off.pacingRate)
+	stats.DeliveryRate = readU64(off.deliveryRate)
+	stats.NotsentBytes = readU32(off.notsentBytes)
+	stats.ReordSeen = readU32(off.reordSeen)
+	stats.Lost = readU32(off.lost)
+
+	return stats
+}
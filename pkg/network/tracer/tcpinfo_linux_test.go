@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package tracer
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTCPInfoOffsets places each field at a fixed 4/8-byte stride so the
+// test buffer layout doesn't have to mirror the kernel's real struct
+// tcp_info, only parseTCPInfo's own decoding.
+var fakeTCPInfoOffsets = tcpInfoFieldOffsets{
+	minRTT:        0,
+	sndCwnd:       4,
+	rcvSsthresh:   8,
+	bytesAcked:    12,
+	bytesReceived: 20,
+	bytesRetrans:  28,
+	totalRetrans:  32,
+	segsOut:       36,
+	segsIn:        40,
+	pacingRate:    44,
+	deliveryRate:  52,
+	notsentBytes:  60,
+	reordSeen:     64,
+	lost:          68,
+}
+
+func TestParseTCPInfo(t *testing.T) {
+	buf := make([]byte, 72)
+	binary.LittleEndian.PutUint32(buf[0:], 1500)
+	binary.LittleEndian.PutUint32(buf[4:], 10)
+	binary.LittleEndian.PutUint32(buf[8:], 64)
+	binary.LittleEndian.PutUint64(buf[12:], 123456)
+	binary.LittleEndian.PutUint64(buf[20:], 654321)
+	binary.LittleEndian.PutUint32(buf[28:], 3)
+	binary.LittleEndian.PutUint32(buf[32:], 7)
+	binary.LittleEndian.PutUint32(buf[36:], 42)
+	binary.LittleEndian.PutUint32(buf[40:], 43)
+	binary.LittleEndian.PutUint64(buf[44:], 1000000)
+	binary.LittleEndian.PutUint64(buf[52:], 900000)
+	binary.LittleEndian.PutUint32(buf[60:], 4096)
+	binary.LittleEndian.PutUint32(buf[64:], 2)
+	binary.LittleEndian.PutUint32(buf[68:], 1)
+
+	stats := parseTCPInfo(buf, fakeTCPInfoOffsets)
+
+	assert.Equal(t, TCPInfoStats{
+		MinRTT:        1500,
+		SndCwnd:       10,
+		RcvSsthresh:   64,
+		BytesAcked:    123456,
+		BytesReceived: 654321,
+		BytesRetrans:  3,
+		TotalRetrans:  7,
+		SegsOut:       42,
+		SegsIn:        43,
+		PacingRate:    1000000,
+		DeliveryRate:  900000,
+		NotsentBytes:  4096,
+		ReordSeen:     2,
+		Lost:          1,
+	}, stats)
+}
+
+func TestParseTCPInfoShortBuffer(t *testing.T) {
+	// A kernel older than the one offsets were guessed against may return a
+	// struct tcp_info shorter than expected; fields beyond the buffer must
+	// come back zero rather than panicking or reading garbage.
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint32(buf[0:], 1500)
+
+	stats := parseTCPInfo(buf, fakeTCPInfoOffsets)
+
+	assert.Equal(t, uint32(1500), stats.MinRTT)
+	assert.Zero(t, stats.PacingRate)
+	assert.Zero(t, stats.DeliveryRate)
+	assert.Zero(t, stats.Lost)
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// otelExceptionEventName is the semantic-conventions event name OTel SDKs
+// use to record a captured exception on a span.
+const otelExceptionEventName = "exception"
+
+// otelExceptionTypeAttr is the attribute carrying the exception's type
+// (e.g. its class name) on an "exception" event.
+const otelExceptionTypeAttr = "exception.type"
+
+// unknownExceptionType is used to key exceptionTypeCounts when an
+// "exception" event doesn't carry an exception.type attribute.
+const unknownExceptionType = "unknown"
+
+// exceptionTypeCounts tallies how many times each exception.type was
+// recorded across a span's "exception" events. It's surfaced alongside the
+// usual error counters so a single noisy exception type doesn't mask others
+// within the same bucket.
+type exceptionTypeCounts map[string]uint64
+
+// spanExceptionEvents inspects span's events and reports whether it carries
+// one or more "exception" events, along with a per-exception.type tally.
+//
+// The intent is to let a span be counted as an error purely from its
+// events, even when its status code isn't Error: several instrumentation
+// libraries record a caught-and-handled exception as an event without
+// flipping the span status, but operators still want that surfaced in
+// error stats. This is not yet wired into the concentrator's stats
+// pipeline; it's a standalone helper today.
+func spanExceptionEvents(span ptrace.Span) (hasException bool, counts exceptionTypeCounts) {
+	events := span.Events()
+	for i := 0; i < events.Len(); i++ {
+		event := events.At(i)
+		if event.Name() != otelExceptionEventName {
+			continue
+		}
+
+		hasException = true
+		typeName := unknownExceptionType
+		if attr, ok := event.Attributes().Get(otelExceptionTypeAttr); ok {
+			typeName = attr.AsString()
+		}
+
+		if counts == nil {
+			counts = make(exceptionTypeCounts)
+		}
+		counts[typeName]++
+	}
+
+	return hasException, counts
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestSpanExceptionEvents(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		events        []map[string]string // event name -> exception.type (empty string for no attribute)
+		hasException  bool
+		expectedCount exceptionTypeCounts
+	}{
+		{
+			name:         "no events",
+			hasException: false,
+		},
+		{
+			name:          "single exception event",
+			events:        []map[string]string{{"exception": "java.lang.NullPointerException"}},
+			hasException:  true,
+			expectedCount: exceptionTypeCounts{"java.lang.NullPointerException": 1},
+		},
+		{
+			name: "multiple exception events on one span",
+			events: []map[string]string{
+				{"exception": "java.lang.NullPointerException"},
+				{"exception": "java.lang.NullPointerException"},
+				{"exception": "java.io.IOException"},
+			},
+			hasException: true,
+			expectedCount: exceptionTypeCounts{
+				"java.lang.NullPointerException": 2,
+				"java.io.IOException":            1,
+			},
+		},
+		{
+			name:          "exception event without a type attribute",
+			events:        []map[string]string{{"exception": ""}},
+			hasException:  true,
+			expectedCount: exceptionTypeCounts{unknownExceptionType: 1},
+		},
+		{
+			name:         "non-exception events are ignored",
+			events:       []map[string]string{{"log": ""}},
+			hasException: false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			span := ptrace.NewSpan()
+			for _, event := range tt.events {
+				for name, excType := range event {
+					e := span.Events().AppendEmpty()
+					e.SetName(name)
+					if excType != "" {
+						e.Attributes().PutStr(otelExceptionTypeAttr, excType)
+					}
+				}
+			}
+
+			hasException, counts := spanExceptionEvents(span)
+			assert.Equal(t, tt.hasException, hasException)
+			assert.Equal(t, tt.expectedCount, counts)
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// otelMappingRulesGlob restricts the mapping rules watcher to YAML files, so
+// editor swap/lock files dropped in the same directory don't trigger reloads
+// or parse errors.
+const otelMappingRulesGlob = "*.yaml"
+
+// otelMappingRulesDebounce coalesces bursts of filesystem events (e.g. an
+// editor doing a write-then-rename) into a single reload.
+const otelMappingRulesDebounce = 200 * time.Millisecond
+
+// otelMappingRulesRescanInterval is the fallback poll period used in case an
+// fsnotify event is missed (e.g. on network filesystems where inotify is
+// unreliable).
+const otelMappingRulesRescanInterval = 30 * time.Second
+
+// OTLPMappingRules is a hot-reloadable set of rules shaped after the
+// config.Config fields of the same name (SpanNameRemappings,
+// Ignore["resource"], ConfiguredPeerTags), meant to eventually let operators
+// update span name remappings, ignored resources and peer tags without an
+// agent restart. Nothing currently reads Rules() from the stats pipeline;
+// this type and its watcher are standalone today.
+type OTLPMappingRules struct {
+	SpanNameRemappings map[string]string `yaml:"span_name_remappings"`
+	IgnoreResources    []string          `yaml:"ignore_resources"`
+	PeerTags           []string          `yaml:"peer_tags"`
+}
+
+// OTLPMappingRulesWatcher watches a directory of YAML files for changes and
+// keeps an atomically-swappable, merged OTLPMappingRules up to date, for
+// whatever eventually calls Rules().
+type OTLPMappingRulesWatcher struct {
+	dir     string
+	current atomic.Pointer[OTLPMappingRules]
+}
+
+// NewOTLPMappingRulesWatcher creates a watcher over dir, performing an
+// initial synchronous load so Rules() is usable as soon as this returns.
+// Call Start to begin watching for subsequent changes.
+func NewOTLPMappingRulesWatcher(dir string) (*OTLPMappingRulesWatcher, error) {
+	w := &OTLPMappingRulesWatcher{dir: dir}
+	w.current.Store(&OTLPMappingRules{})
+
+	if err := w.reload(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Rules returns the currently active mapping rules. It is safe to call
+// concurrently with Start's reloads.
+func (w *OTLPMappingRulesWatcher) Rules() *OTLPMappingRules {
+	return w.current.Load()
+}
+
+// Start watches w's directory for changes until ctx is canceled, reloading
+// and atomically swapping in new rules as files are added, changed or
+// removed. It also re-scans on a fixed interval as a fallback in case
+// filesystem events are dropped.
+func (w *OTLPMappingRulesWatcher) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP mapping rules watcher: %w", err)
+	}
+
+	if err := watcher.Add(w.dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch %s for OTLP mapping rules: %w", w.dir, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var debounce *time.Timer
+		reload := func() {
+			if err := w.reload(); err != nil {
+				log.Warnf("failed to reload OTLP mapping rules from %s: %v", w.dir, err)
+			}
+		}
+
+		ticker := time.NewTicker(otelMappingRulesRescanInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reload()
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Ext(event.Name) != ".yaml" {
+					continue
+				}
+				if debounce == nil {
+					debounce = time.AfterFunc(otelMappingRulesDebounce, reload)
+				} else {
+					debounce.Reset(otelMappingRulesDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warnf("OTLP mapping rules watcher error on %s: %v", w.dir, err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// reload re-reads every file in w.dir matching otelMappingRulesGlob, merges
+// them into a single OTLPMappingRules and atomically swaps it in. Files are
+// processed in name order so that, for overlapping keys, the result is
+// deterministic.
+func (w *OTLPMappingRulesWatcher) reload() error {
+	matches, err := filepath.Glob(filepath.Join(w.dir, otelMappingRulesGlob))
+	if err != nil {
+		return err
+	}
+
+	merged := &OTLPMappingRules{SpanNameRemappings: make(map[string]string)}
+	for _, path := range matches {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var rules OTLPMappingRules
+		if err := yaml.Unmarshal(raw, &rules); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		for k, v := range rules.SpanNameRemappings {
+			merged.SpanNameRemappings[k] = v
+		}
+		merged.IgnoreResources = append(merged.IgnoreResources, rules.IgnoreResources...)
+		merged.PeerTags = append(merged.PeerTags, rules.PeerTags...)
+	}
+
+	w.current.Store(merged)
+	return nil
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMappingRulesFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestOTLPMappingRulesWatcherInitialLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeMappingRulesFile(t, dir, "rules.yaml", `
+span_name_remappings:
+  old_name: new_name
+ignore_resources:
+  - GET /health
+peer_tags:
+  - rpc.service
+`)
+
+	w, err := NewOTLPMappingRulesWatcher(dir)
+	require.NoError(t, err)
+
+	rules := w.Rules()
+	assert.Equal(t, "new_name", rules.SpanNameRemappings["old_name"])
+	assert.Equal(t, []string{"GET /health"}, rules.IgnoreResources)
+	assert.Equal(t, []string{"rpc.service"}, rules.PeerTags)
+}
+
+func TestOTLPMappingRulesWatcherReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	writeMappingRulesFile(t, dir, "rules.yaml", `
+span_name_remappings:
+  old_name: new_name
+`)
+
+	w, err := NewOTLPMappingRulesWatcher(dir)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	require.NoError(t, w.Start(ctx))
+
+	writeMappingRulesFile(t, dir, "rules.yaml", `
+span_name_remappings:
+  old_name: updated_name
+`)
+
+	assert.Eventually(t, func() bool {
+		return w.Rules().SpanNameRemappings["old_name"] == "updated_name"
+	}, 5*time.Second, 10*time.Millisecond, "watcher should pick up the updated rules file")
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package recovery provides panic-recovery middleware for workloadmeta event
+// handlers. A panic raised by a downstream handler (a buggy provider, an
+// unexpected entity cast, ...) would otherwise crash the subscriber's event
+// loop; WithRecovery turns it into a regular error instead, analogous to a
+// gRPC recovery interceptor.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+// EventHandler processes a single workloadmeta event, matching the shape
+// used throughout the codebase's event-loop subscribers.
+type EventHandler func(ctx context.Context, evt workloadmeta.Event) error
+
+// PanicError wraps a panic recovered from an EventHandler, preserving the
+// original panic value and the stack captured at the point it occurred.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in workloadmeta event handler: %v\n%s", e.Value, e.Stack)
+}
+
+var recoveredPanics = telemetry.NewCounter(
+	"workloadmeta",
+	"event_handler_panics",
+	nil,
+	"Number of panics recovered from workloadmeta event handlers wrapped with WithRecovery",
+)
+
+// WithRecovery wraps handler so that a panic raised while processing an
+// event is recovered, converted into a *PanicError, and returned as a
+// regular error instead of propagating up and crashing the caller's event
+// loop. Each recovered panic also increments a telemetry counter so it stays
+// visible even though the loop keeps running.
+func WithRecovery(handler EventHandler) EventHandler {
+	return func(ctx context.Context, evt workloadmeta.Event) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				recoveredPanics.Inc()
+				err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+
+		return handler(ctx, evt)
+	}
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package recovery
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
+)
+
+func TestWithRecoveryPassesThroughOnSuccess(t *testing.T) {
+	handler := WithRecovery(func(_ context.Context, _ workloadmeta.Event) error {
+		return nil
+	})
+
+	assert.NoError(t, handler(context.Background(), workloadmeta.Event{}))
+}
+
+func TestWithRecoveryPassesThroughErrors(t *testing.T) {
+	wantErr := errors.New("boom")
+	handler := WithRecovery(func(_ context.Context, _ workloadmeta.Event) error {
+		return wantErr
+	})
+
+	assert.Equal(t, wantErr, handler(context.Background(), workloadmeta.Event{}))
+}
+
+func TestWithRecoveryRecoversPanic(t *testing.T) {
+	handler := WithRecovery(func(_ context.Context, _ workloadmeta.Event) error {
+		panic("downstream provider exploded")
+	})
+
+	err := handler(context.Background(), workloadmeta.Event{})
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "downstream provider exploded", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+}
@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/test-infra-definitions/components/os"
+
+	"github.com/DataDog/datadog-agent/test/new-e2e/pkg/e2e"
+	"github.com/DataDog/datadog-agent/test/new-e2e/pkg/environments"
+	awshost "github.com/DataDog/datadog-agent/test/new-e2e/pkg/provisioners/aws/host"
+)
+
+// baseConfigSuite holds the environment and the per-OS knowledge shared by
+// every "agent config" suite (linux, windows, macos). Per-OS suites only
+// need to supply the osOption used to provision their host; the assertions
+// themselves live here so they aren't duplicated per OS.
+type baseConfigSuite struct {
+	e2e.BaseSuite[environments.Host]
+	osOption awshost.ProvisionerOption
+}
+
+// configOSPaths captures the parts of "agent config" behavior that differ by
+// OS: where the agent binary lives, where its config file lives, and how to
+// restart the service after editing it.
+type configOSPaths struct {
+	agentBinary       string
+	configFile        string
+	restartServiceCmd string
+}
+
+var configPathsByFamily = map[os.Family]configOSPaths{
+	os.LinuxFamily: {
+		agentBinary:       "/opt/datadog-agent/bin/agent/agent",
+		configFile:        "/etc/datadog-agent/datadog.yaml",
+		restartServiceCmd: "sudo service datadog-agent restart",
+	},
+	os.WindowsFamily: {
+		agentBinary:       `C:\Program Files\Datadog\Datadog Agent\bin\agent.exe`,
+		configFile:        `C:\ProgramData\Datadog\datadog.yaml`,
+		restartServiceCmd: "Restart-Service -Name DatadogAgent",
+	},
+	os.MacOSFamily: {
+		agentBinary:       "/opt/datadog-agent/bin/agent/agent",
+		configFile:        "/opt/datadog-agent/etc/datadog.yaml",
+		restartServiceCmd: "launchctl kickstart -k system/com.datadoghq.agent",
+	},
+}
+
+// paths returns the path/command set for the suite's current host OS,
+// failing the test immediately if the OS isn't registered above.
+func (v *baseConfigSuite) paths() configOSPaths {
+	family := v.Env().RemoteHost.OSFamily
+	paths, ok := configPathsByFamily[family]
+	if !ok {
+		v.T().Fatalf("agent-subcommands/config: no paths registered for OS family %v", family)
+	}
+	return paths
+}
+
+// agentConfigCommand builds the remote "agent config ..." command line,
+// quoting the agent binary path with plain double quotes rather than
+// fmt's "%q" verb: "%q" applies Go string-literal escaping, which doubles
+// every backslash, corrupting a Windows path like
+// `C:\Program Files\Datadog\Datadog Agent\bin\agent.exe` before it ever
+// reaches the remote shell.
+func (v *baseConfigSuite) agentConfigCommand(args ...string) string {
+	return fmt.Sprintf(`"%s" config %s`, v.paths().agentBinary, strings.Join(args, " "))
+}
+
+// testConfigGet exercises "agent config get <key>" against a key that's
+// always set (the config file path itself), independent of OS.
+func (v *baseConfigSuite) testConfigGet() {
+	out := v.Env().RemoteHost.MustExecute(v.agentConfigCommand("get", "config_path"))
+	v.Assert().Contains(strings.TrimSpace(out), v.paths().configFile)
+}
+
+// testConfigSet exercises "agent config set <key> <value>" followed by a
+// restart, then confirms the new value took effect via "agent config get".
+func (v *baseConfigSuite) testConfigSet() {
+	v.Env().RemoteHost.MustExecute(v.agentConfigCommand("set", "log_level", "debug"))
+	v.Env().RemoteHost.MustExecute(v.paths().restartServiceCmd)
+
+	out := v.Env().RemoteHost.MustExecute(v.agentConfigCommand("get", "log_level"))
+	v.Assert().Contains(strings.TrimSpace(out), "debug")
+}
+
+// testConfigList exercises "agent config list" and checks that it at least
+// enumerates the key we just set.
+func (v *baseConfigSuite) testConfigList() {
+	out := v.Env().RemoteHost.MustExecute(v.agentConfigCommand("list"))
+	v.Assert().Contains(out, "log_level")
+}
+
+// testConfigReload exercises "agent config" with no subcommand, which
+// should print the agent's full runtime configuration without erroring.
+func (v *baseConfigSuite) testConfigReload() {
+	out, err := v.Env().RemoteHost.Execute(v.agentConfigCommand())
+	v.Assert().NoError(err)
+	v.Assert().NotEmpty(out)
+}
+
+// testConfigInvalidKey exercises "agent config get <key>" for a key that
+// doesn't exist, which should fail rather than silently printing nothing.
+func (v *baseConfigSuite) testConfigInvalidKey() {
+	_, err := v.Env().RemoteHost.Execute(v.agentConfigCommand("get", "this_key_does_not_exist"))
+	v.Assert().Error(err)
+}
+
+// TestConfigSubcommand runs the shared get/set/list/reload/invalid-key
+// assertions as subtests. Every per-OS suite gets this for free by
+// embedding baseConfigSuite.
+func (v *baseConfigSuite) TestConfigSubcommand() {
+	v.Run("get", v.testConfigGet)
+	v.Run("set", v.testConfigSet)
+	v.Run("list", v.testConfigList)
+	v.Run("reload", v.testConfigReload)
+	v.Run("invalid-key", v.testConfigInvalidKey)
+}
@@ -0,0 +1,33 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/DataDog/test-infra-definitions/components/os"
+	"github.com/DataDog/test-infra-definitions/scenarios/aws/ec2"
+
+	"github.com/DataDog/datadog-agent/test/new-e2e/pkg/e2e"
+	awshost "github.com/DataDog/datadog-agent/test/new-e2e/pkg/provisioners/aws/host"
+)
+
+type macosConfigSuite struct {
+	baseConfigSuite
+}
+
+// TestMacosConfigSuite is skipped until our provisioner can actually stand
+// up a macOS host (today awshost only provisions Linux and Windows EC2
+// instances); it's kept registered so there is a single place to flip macOS
+// coverage on once that support lands, instead of macOS quietly never being
+// covered. TestConfigSuiteMatrix does not include macOS.
+func TestMacosConfigSuite(t *testing.T) {
+	t.Skip("macOS provisioner not yet available for agent-subcommands e2e suites")
+
+	osOption := awshost.WithEC2InstanceOptions(ec2.WithOS(os.MacosDefault))
+	t.Parallel()
+	e2e.Run(t, &macosConfigSuite{baseConfigSuite: baseConfigSuite{osOption: osOption}}, e2e.WithProvisioner(awshost.ProvisionerNoFakeIntake(osOption)))
+}
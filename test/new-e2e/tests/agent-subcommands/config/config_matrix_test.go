@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+
+	"github.com/DataDog/test-infra-definitions/components/os"
+	"github.com/DataDog/test-infra-definitions/scenarios/aws/ec2"
+
+	"github.com/DataDog/datadog-agent/test/new-e2e/pkg/e2e"
+	awshost "github.com/DataDog/datadog-agent/test/new-e2e/pkg/provisioners/aws/host"
+)
+
+// matrixConfigSuite is the suite type driven by TestConfigSuiteMatrix: it
+// doesn't add anything over baseConfigSuite, it just gives the matrix its
+// own suite identity distinct from the named per-OS suites in this package.
+type matrixConfigSuite struct {
+	baseConfigSuite
+}
+
+// TestConfigSuiteMatrix runs the shared get/set/list/reload/invalid-key
+// assertions against every distro we claim to support in a single job,
+// rather than relying on remembering to add a dedicated TestXConfigSuite
+// whenever a new distro is added. The per-OS suites in this package remain the place
+// to add OS-specific subtests that don't make sense across the whole matrix.
+func TestConfigSuiteMatrix(t *testing.T) {
+	matrix := []struct {
+		name         string
+		osDescriptor os.Descriptor
+	}{
+		{"ubuntu", os.UbuntuDefault},
+		{"amazon-linux-2", os.AmazonLinux2},
+		{"redhat", os.RedHatDefault},
+		{"windows", os.WindowsDefault},
+	}
+
+	for _, tt := range matrix {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			osOption := awshost.WithEC2InstanceOptions(ec2.WithOS(tt.osDescriptor))
+			e2e.Run(t, &matrixConfigSuite{baseConfigSuite: baseConfigSuite{osOption: osOption}}, e2e.WithProvisioner(awshost.ProvisionerNoFakeIntake(osOption)))
+		})
+	}
+}